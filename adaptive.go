@@ -0,0 +1,112 @@
+package keysort
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// AdaptiveThreshold is the mean time that the first few calls to Key() must
+// take for KeysortAdaptive to go on memoizing keys. Below it, a key is taken to
+// be cheaper to recompute than to look up in the memo.
+const AdaptiveThreshold = 100 * time.Nanosecond
+
+// adaptiveSampleSize is how many calls to Key() KeysortAdaptive times.
+const adaptiveSampleSize = 8
+
+// adaptiveSortable is a keySortable that may have decided not to memoize.
+type adaptiveSortable struct {
+	*keySortable
+	memoized bool
+}
+
+// KeysortAdaptive is like Keysort, but first times the keys of up to the first
+// eight elements. If they took less than AdaptiveThreshold each, on average,
+// the memo would only slow the sort down, so Less computes both keys afresh
+// each time, like Unmemoized, instead. Unlike Unmemoized, any key that fails is
+// still recorded, and returned by Errors().
+//
+// Only the computation of the keys is timed, not the memo. The timed keys are
+// memoized either way, so their cost is not wasted.
+func KeysortAdaptive(wrapped Interface) *adaptiveSortable {
+	return KeysortAdaptiveThreshold(wrapped, AdaptiveThreshold)
+}
+
+// KeysortAdaptiveThreshold is like KeysortAdaptive, but goes on memoizing keys
+// only if they took at least threshold each, on average, instead of
+// AdaptiveThreshold.
+func KeysortAdaptiveThreshold(wrapped Interface, threshold time.Duration) *adaptiveSortable {
+	ks := Keysort(wrapped)
+	n := min(adaptiveSampleSize, ks.Len())
+	elapsed := ks.sampleKeys(n)
+	return &adaptiveSortable{ks, n == 0 || elapsed >= threshold*time.Duration(n)}
+}
+
+// sampleKeys memoizes the keys of the first n elements, returning how long it
+// took to compute them.
+func (ks *keySortable) sampleKeys(n int) (elapsed time.Duration) {
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		value, err := ks.callKey(i)
+		elapsed += time.Since(start)
+
+		ks.Lock()
+		ks.storeKey(ks.swaps[i], value, err)
+		ks.Unlock()
+	}
+	return elapsed
+}
+
+// Memoized reports whether keys are being memoized.
+func (a *adaptiveSortable) Memoized() bool {
+	return a.memoized
+}
+
+// Less is designed to implement sort.Interface. It compares memoized keys, or
+// computes them afresh if KeysortAdaptive judged them cheap enough.
+func (a *adaptiveSortable) Less(i, j int) bool {
+	if a.memoized {
+		return a.keySortable.Less(i, j)
+	}
+	atomic.AddInt64(&a.comparisons, 1)
+	IValue, ok := a.freshKey(i)
+	if !ok {
+		return false
+	}
+	JValue, ok := a.freshKey(j)
+	if !ok {
+		return false
+	}
+	return a.wrapped.LessVal(IValue, JValue)
+}
+
+// freshKey computes the key of the element currently at index i as Key does,
+// with any timeout, retries and normalization, but without memoizing the
+// result. ok is false if it fails, in which case the error is recorded.
+func (a *adaptiveSortable) freshKey(i int) (value interface{}, ok bool) {
+	value, err := a.callKey(i)
+	if err != nil {
+		a.recordError(a.swaps[i], err)
+		return nil, false
+	}
+	return value, true
+}
+
+// Sort is like keySortable.Sort, using the adaptive Less.
+func (a *adaptiveSortable) Sort() error {
+	defer a.acquire()()
+	sort.Sort(a)
+	return a.Errors()
+}
+
+// Stable is like keySortable.Stable, using the adaptive Less.
+func (a *adaptiveSortable) Stable() error {
+	defer a.acquire()()
+	sort.Stable(a)
+	return a.Errors()
+}
+
+// SortStable is the same as Stable, named to pair with Sort.
+func (a *adaptiveSortable) SortStable() error {
+	return a.Stable()
+}
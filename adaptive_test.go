@@ -0,0 +1,86 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestKeysortAdaptiveCheap(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	// Generous, so that even a slowed-down test binary finds ByIntKey cheap.
+	ks := KeysortAdaptiveThreshold(specimen, time.Millisecond)
+	if ks.Memoized() {
+		t.Errorf("Expected cheap keys not to be memoized")
+	}
+	if err := ks.Sort(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !sort.IsSorted(specimen) {
+		t.Errorf("KeysortAdaptive failed for ByIntKey")
+	}
+	if ks.KeyCalls() <= SPECIMEN_SIZE {
+		t.Errorf("Expected keys to be recomputed, got %d calls", ks.KeyCalls())
+	}
+}
+
+func TestKeysortAdaptiveExpensive(t *testing.T) {
+	specimen := ByIntKeySlow{GenSpecimen(SPECIMEN_SIZE), 10 * time.Microsecond}
+
+	ks := KeysortAdaptive(specimen)
+	if !ks.Memoized() {
+		t.Errorf("Expected expensive keys to be memoized")
+	}
+	if err := ks.Sort(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !sort.IsSorted(ByIntKey{specimen.SpecimenSliceSorter}) {
+		t.Errorf("KeysortAdaptive failed for ByIntKeySlow")
+	}
+	if ks.KeyCalls() != SPECIMEN_SIZE {
+		t.Errorf("Expected %d key calls, got %d", SPECIMEN_SIZE, ks.KeyCalls())
+	}
+}
+
+func TestKeysortAdaptiveErrors(t *testing.T) {
+	ks := KeysortAdaptiveThreshold(ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)}, time.Millisecond)
+	if err := ks.Sort(); err == nil {
+		t.Errorf("Errors were expected.")
+	}
+}
+
+func TestKeysortAdaptiveNormalizer(t *testing.T) {
+	specimen := MixedNumbers{3, 1.5, 2, 0.5, 4.25, 1, 6, 5.5, 7, 8.25}
+
+	// Keys computed afresh must still be normalized before LessVal sees them.
+	ks := KeysortAdaptiveThreshold(specimen, time.Hour)
+	if ks.Memoized() {
+		t.Fatalf("Expected cheap keys not to be memoized")
+	}
+	if err := ks.Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := MixedNumbers{0.5, 1, 1.5, 2, 3, 4.25, 5.5, 6, 7, 8.25}
+	for i := range expected {
+		if specimen[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, specimen)
+			break
+		}
+	}
+}
+
+func TestKeysortAdaptiveKeyPanics(t *testing.T) {
+	specimen := ByIntKeyPanics{GenSpecimen(SPECIMEN_SIZE), 2}
+
+	// A panic while sampling is recorded like any other failed key.
+	ks := KeysortAdaptiveThreshold(specimen, time.Hour)
+	primingError, ok := ks.Errors().(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError, got %v", ks.Errors())
+	}
+	if _, ok := primingError.ErrorAt(2).(KeyPanicError); !ok {
+		t.Errorf("Expected a KeyPanicError at 2, got %v", primingError.Errors)
+	}
+}
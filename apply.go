@@ -0,0 +1,25 @@
+package keysort
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ApplyPermutation reorders another container of Len() elements, through its
+// swap function, in the same way as the wrapped container has been reordered
+// so far, so that a parallel slice left out of Swap can be brought into line.
+// It makes at most Len() calls to swap.
+func (ks *keySortable) ApplyPermutation(swap func(i, j int)) {
+	applyPermutation(swap, ks.SortedIndices())
+}
+
+// ApplyTo is like ApplyPermutation, for a target that implements
+// sort.Interface. An error matching ErrLenMismatch is returned, and target is
+// left alone, if it does not have the same length as the wrapped container.
+func (ks *keySortable) ApplyTo(target sort.Interface) error {
+	if target.Len() != ks.Len() {
+		return fmt.Errorf("%w: target has %d elements, expected %d", ErrLenMismatch, target.Len(), ks.Len())
+	}
+	ks.ApplyPermutation(target.Swap)
+	return nil
+}
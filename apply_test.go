@@ -0,0 +1,62 @@
+package keysort
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+func TestApplyTo(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	// names runs in parallel with specimen, but is not swapped with it.
+	names := make(sort.StringSlice, len(specimen))
+	for i := range specimen {
+		names[i] = specimen[i].StringKey
+	}
+
+	ks := Keysort(ByIntKey{specimen})
+	sort.Sort(ks)
+	if err := ks.ApplyTo(names); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for i := range specimen {
+		if names[i] != specimen[i].StringKey {
+			t.Errorf("Expected %q at %d, got %q", specimen[i].StringKey, i, names[i])
+		}
+	}
+}
+
+func TestApplyToLenMismatch(t *testing.T) {
+	ks := Keysort(ByIntKey{GenSpecimen(SPECIMEN_SIZE)})
+	sort.Sort(ks)
+
+	target := sort.IntSlice(make([]int, SPECIMEN_SIZE-1))
+	if err := ks.ApplyTo(target); !errors.Is(err, ErrLenMismatch) {
+		t.Errorf("Expected ErrLenMismatch, got %v", err)
+	}
+}
+
+func TestKeysortApplyPermutation(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	original := append(SpecimenSliceSorter{}, specimen...)
+
+	ks := Keysort(ByStringKey{specimen})
+	sort.Sort(ks)
+
+	other := append(SpecimenSliceSorter{}, original...)
+	calls := 0
+	ks.ApplyPermutation(func(i, j int) {
+		calls++
+		other.Swap(i, j)
+	})
+
+	for i := range specimen {
+		if other[i] != specimen[i] {
+			t.Errorf("Expected the same order as the sorted container at %d", i)
+		}
+	}
+	if calls > SPECIMEN_SIZE {
+		t.Errorf("Expected at most %d swaps, got %d", SPECIMEN_SIZE, calls)
+	}
+}
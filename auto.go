@@ -0,0 +1,37 @@
+package keysort
+
+import "runtime"
+
+// KeyCostKind describes what limits the speed of a Key() function, for
+// AutoParallelism.
+type KeyCostKind int
+
+const (
+	// CPUBound keys are limited by computation, so running more of them at
+	// once than there are CPUs does not help.
+	CPUBound KeyCostKind = iota
+	// IOBound keys spend most of their time waiting, for instance on the
+	// network, so many more of them can usefully run at once.
+	IOBound
+)
+
+// IOBoundFactor is how many IOBound keys AutoParallelism runs at once per CPU.
+const IOBoundFactor = 16
+
+// AutoParallelism returns a parallelism to prime wrapped with, for keys of the
+// given kind: runtime.GOMAXPROCS for CPUBound keys, and IOBoundFactor times as
+// many for IOBound keys. It never exceeds Len(), since extra goroutines would
+// have nothing to do, but is always at least 1.
+func AutoParallelism(wrapped Interface, keyKind KeyCostKind) int {
+	return AutoParallelismFactor(wrapped, keyKind, IOBoundFactor)
+}
+
+// AutoParallelismFactor is like AutoParallelism, but runs ioBoundFactor IOBound
+// keys at once per CPU instead of IOBoundFactor.
+func AutoParallelismFactor(wrapped Interface, keyKind KeyCostKind, ioBoundFactor int) int {
+	parallelism := runtime.GOMAXPROCS(-1)
+	if keyKind == IOBound {
+		parallelism *= ioBoundFactor
+	}
+	return max(1, min(parallelism, wrapped.Len()))
+}
@@ -0,0 +1,37 @@
+package keysort
+
+import (
+	"runtime"
+	"sort"
+	"testing"
+)
+
+func TestAutoParallelism(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(50 * SPECIMEN_SIZE)}
+	procs := runtime.GOMAXPROCS(-1)
+
+	if got, expected := AutoParallelism(specimen, CPUBound), min(procs, specimen.Len()); got != expected {
+		t.Errorf("Expected %d for CPUBound, got %d", expected, got)
+	}
+	if got, expected := AutoParallelism(specimen, IOBound), min(procs*IOBoundFactor, specimen.Len()); got != expected {
+		t.Errorf("Expected %d for IOBound, got %d", expected, got)
+	}
+	if got, expected := AutoParallelismFactor(specimen, IOBound, 2), min(procs*2, specimen.Len()); got != expected {
+		t.Errorf("Expected %d for IOBound with a factor of 2, got %d", expected, got)
+	}
+	if got := AutoParallelism(ByIntKey{GenSpecimen(2)}, IOBound); got > 2 {
+		t.Errorf("Expected at most 2 for 2 elements, got %d", got)
+	}
+	if got := AutoParallelism(ByIntKey{}, CPUBound); got != 1 {
+		t.Errorf("Expected 1 for no elements, got %d", got)
+	}
+
+	ks := PrimedKeysort(specimen, AutoParallelism(specimen, IOBound))
+	if !ks.FullyPrimed() {
+		t.Errorf("Expected every key to be primed")
+	}
+	sort.Sort(ks)
+	if !sort.IsSorted(specimen) {
+		t.Errorf("Failed to sort after priming with AutoParallelism")
+	}
+}
@@ -0,0 +1,36 @@
+package keysort
+
+import (
+	"context"
+	"sync"
+)
+
+// BackgroundPrimedKeysort is like PrimedKeysort, but returns immediately,
+// memoizing keys with parallelism goroutines while the caller sorts. Keys that
+// the sort needs before they have been memoized are computed on demand, and a
+// key that is already being computed is waited for rather than computed again.
+// Close stops any priming that is still going on.
+func BackgroundPrimedKeysort(wrapped Interface, parallelism int) *keySortable {
+	ks := Keysort(wrapped)
+	ks.inflight = map[int]chan struct{}{}
+	ks.swapLock = &sync.RWMutex{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	ks.stopBackground = func() {
+		cancel()
+		<-done
+	}
+
+	go func() {
+		defer close(done)
+		ks.memoizeWith(ctx, parallelism, ks.allIndexes, func(i int) {
+			// Hold off Swap, so that the element at i is the one whose key
+			// is memoized.
+			ks.swapLock.RLock()
+			defer ks.swapLock.RUnlock()
+			ks.Key(i)
+		})
+	}()
+	return ks
+}
@@ -0,0 +1,43 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestBackgroundPrimedKeysort(t *testing.T) {
+	var count int64
+	specimen := ByIntKeyCounted{GenSpecimen(SPECIMEN_SIZE), &count}
+
+	ks := BackgroundPrimedKeysort(specimen, 4)
+	sort.Sort(ks)
+	ks.Close()
+
+	if !sort.IsSorted(ByIntKey{specimen.SpecimenSliceSorter}) {
+		t.Errorf("BackgroundPrimedKeysort failed to sort")
+	}
+	if calls := ks.KeyCalls(); calls > SPECIMEN_SIZE {
+		t.Errorf("Expected at most %d Key calls, got %d", SPECIMEN_SIZE, calls)
+	}
+	if count > SPECIMEN_SIZE {
+		t.Errorf("Expected at most %d calls to wrapped Key, got %d", SPECIMEN_SIZE, count)
+	}
+}
+
+func TestBackgroundPrimedKeysortSlow(t *testing.T) {
+	specimen := ByIntKeySlow{GenSpecimen(SPECIMEN_SIZE), time.Millisecond}
+
+	ks := BackgroundPrimedKeysort(specimen, 2)
+	sort.Sort(ks)
+	ks.Close()
+	// Closing again is harmless.
+	ks.Close()
+
+	if !sort.IsSorted(ByIntKey{specimen.SpecimenSliceSorter}) {
+		t.Errorf("BackgroundPrimedKeysort failed to sort slow keys")
+	}
+	if calls := ks.KeyCalls(); calls > SPECIMEN_SIZE {
+		t.Errorf("Expected at most %d Key calls, got %d", SPECIMEN_SIZE, calls)
+	}
+}
@@ -0,0 +1,117 @@
+package keysort
+
+import (
+	"context"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// defaultBatchSize is how many indices are passed to each call to KeyBatch,
+// unless PrimedKeysortBatched says otherwise.
+const defaultBatchSize = 64
+
+// BatchKeyer may optionally be implemented by an Interface that can compute
+// many keys at once more cheaply than one at a time. If it is, priming calls
+// KeyBatch with batches of indices instead of calling Key for each of them.
+//
+// KeyBatch returns the keys and errors of the elements currently at indices,
+// by index. An index that is in neither map is left unmemoized, and its key is
+// computed by Key when it is needed.
+type BatchKeyer interface {
+	KeyBatch(indices []int) (map[int]interface{}, map[int]error)
+}
+
+// PrimedKeysortBatched is like PrimedKeysort, but if wrapped implements
+// BatchKeyer, keys are computed batchSize at a time. If batchSize is less than
+// one, a default size is used.
+func PrimedKeysortBatched(wrapped Interface, parallelism int, batchSize int) *keySortable {
+	ks := Keysort(wrapped)
+	ks.batchSize = batchSize
+	ks.memoize(parallelism, ks.allIndexes)
+	return ks
+}
+
+// memoizeBatches is like memoizeContext, for a wrapped Interface that
+// implements BatchKeyer. The indices from genIndexes are grouped into batches,
+// which are handed out to the goroutines in turn.
+func (ks *keySortable) memoizeBatches(ctx context.Context, parallelism int, genIndexes func(chan<- int), batcher BatchKeyer) {
+	batchSize := ks.batchSize
+	if batchSize < 1 {
+		batchSize = defaultBatchSize
+	}
+
+	generated := make(chan int)
+	go ks.dedupIndexes(genIndexes, generated)
+	batches := [][]int{}
+	batch := []int{}
+	for i := range generated {
+		batch = append(batch, i)
+		if len(batch) == batchSize {
+			batches = append(batches, batch)
+			batch = []int{}
+		}
+	}
+	if len(batch) != 0 {
+		batches = append(batches, batch)
+	}
+
+	ks.memoizeWith(ctx, parallelism, func(bChan chan<- int) {
+		for b := range batches {
+			bChan <- b
+		}
+		close(bChan)
+	}, func(b int) {
+		ks.keyBatch(batcher, batches[b])
+	})
+}
+
+// keyBatch memoizes the keys of the elements currently at indices with a
+// single call to KeyBatch, skipping any that are already memoized.
+func (ks *keySortable) keyBatch(batcher BatchKeyer, indices []int) {
+	pending := []int{}
+	ks.Lock()
+	for _, i := range indices {
+		if _, ok := ks.lookupKey(ks.swaps[i]); !ok {
+			pending = append(pending, i)
+		}
+	}
+	ks.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	atomic.AddInt64(&ks.keyCalls, int64(len(pending)))
+	values, errs := ks.safeKeyBatch(batcher, pending)
+
+	ks.Lock()
+	defer ks.Unlock()
+	for _, i := range pending {
+		originalIndex := ks.swaps[i]
+		value, computed := values[i]
+		err, failed := errs[i]
+		if !computed && !failed {
+			continue
+		}
+		value, err = ks.finishKey(i, value, err)
+		// As in Key, a value memoized in the meantime wins.
+		if _, ok := ks.lookupKey(originalIndex); ok {
+			continue
+		}
+		ks.storeKey(originalIndex, value, err)
+	}
+}
+
+// safeKeyBatch calls batcher.KeyBatch(indices), converting a panic into a
+// KeyPanicError for every index in the batch.
+func (ks *keySortable) safeKeyBatch(batcher BatchKeyer, indices []int) (values map[int]interface{}, errs map[int]error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			values, errs = nil, map[int]error{}
+			for _, i := range indices {
+				errs[i] = KeyPanicError{Index: ks.swaps[i], Value: r, Stack: stack}
+			}
+		}
+	}()
+	return batcher.KeyBatch(indices)
+}
@@ -0,0 +1,72 @@
+package keysort
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// ByIntKeyBatched records every call to KeyBatch.
+type ByIntKeyBatched struct {
+	ByIntKey
+	lock    *sync.Mutex
+	batches *[][]int
+}
+
+func (s ByIntKeyBatched) KeyBatch(indices []int) (map[int]interface{}, map[int]error) {
+	s.lock.Lock()
+	*s.batches = append(*s.batches, append([]int{}, indices...))
+	s.lock.Unlock()
+
+	values, errs := map[int]interface{}{}, map[int]error{}
+	for _, i := range indices {
+		if s.At(i).StringKey == "aaa" {
+			errs[i] = fmt.Errorf("Blah")
+		} else {
+			values[i] = s.At(i).IntKey
+		}
+	}
+	return values, errs
+}
+
+func newByIntKeyBatched() ByIntKeyBatched {
+	return ByIntKeyBatched{ByIntKey{GenSpecimen(SPECIMEN_SIZE)}, &sync.Mutex{}, &[][]int{}}
+}
+
+func TestPrimedKeysortBatch(t *testing.T) {
+	specimen := newByIntKeyBatched()
+
+	ks := PrimedKeysortBatched(specimen, -1, SPECIMEN_SIZE)
+
+	if len(*specimen.batches) != 1 || len((*specimen.batches)[0]) != SPECIMEN_SIZE {
+		t.Fatalf("Expected a single batch of %d, got %v", SPECIMEN_SIZE, *specimen.batches)
+	}
+	// The key that failed is not memoized.
+	if memoized := countMemoized(ks); memoized != SPECIMEN_SIZE-1 {
+		t.Errorf("Expected %d memoized keys, got %d", SPECIMEN_SIZE-1, memoized)
+	}
+	primingError, ok := ks.Errors().(PrimingError)
+	if !ok || primingError.ErrorAt(1) == nil {
+		t.Errorf("Expected an error at index 1, got %v", ks.Errors())
+	}
+}
+
+func TestPrimedKeysortBatchSize(t *testing.T) {
+	specimen := newByIntKeyBatched()
+	// Don't fail, so that the sort can be checked.
+	specimen.SpecimenSliceSorter[1].StringKey = "bbb"
+
+	ks := PrimedKeysortBatched(specimen, 2, 6)
+	sort.Sort(ks)
+
+	if len(*specimen.batches) != 4 {
+		t.Errorf("Expected 4 batches, got %v", *specimen.batches)
+	}
+	if calls := ks.KeyCalls(); calls != SPECIMEN_SIZE {
+		t.Errorf("Expected %d keys computed, got %d", SPECIMEN_SIZE, calls)
+	}
+	if !sort.IsSorted(specimen.ByIntKey) {
+		t.Errorf("PrimedKeysortBatched failed to sort")
+	}
+}
@@ -0,0 +1,55 @@
+package keysort
+
+// KeysortBudget sorts wrapped, but gives up once maxComparisons keys have been
+// compared, leaving it partially sorted. It reports whether the sort
+// completed; it also reports false if any key failed.
+//
+// It is a quicksort that partitions the largest ranges first, so that when it
+// stops early, every element is already close to its final position: each
+// element is in the right range of those partitioned so far. This trades
+// accuracy for latency on large inputs that can tolerate some disorder.
+func KeysortBudget(wrapped Interface, maxComparisons int) (completed bool) {
+	ks := Keysort(wrapped)
+
+	// ranges is a queue of half-open ranges still to be sorted, which are
+	// visited breadth-first.
+	ranges := [][2]int{{0, ks.Len()}}
+	for len(ranges) > 0 {
+		lo, hi := ranges[0][0], ranges[0][1]
+		ranges = ranges[1:]
+		if hi-lo < 2 {
+			continue
+		}
+
+		// Use the middle element as the pivot, so that sorted input is not
+		// the worst case, and move it to the front. The range is then
+		// partitioned three ways, into [lo, lt) less than the pivot,
+		// [lt, i) equal to it and [gt, hi) greater than it, so that runs of
+		// equal keys are not partitioned again. The element at lt is always
+		// equal to the pivot, and stands in for it.
+		ks.Swap(lo, lo+(hi-lo)/2)
+		lt, i, gt := lo, lo+1, hi
+		for i < gt {
+			if ks.Comparisons() >= maxComparisons {
+				return false
+			}
+			if ks.Less(i, lt) {
+				ks.Swap(lt, i)
+				lt++
+				i++
+				continue
+			}
+			if ks.Comparisons() >= maxComparisons {
+				return false
+			}
+			if ks.Less(lt, i) {
+				gt--
+				ks.Swap(i, gt)
+			} else {
+				i++
+			}
+		}
+		ranges = append(ranges, [2]int{lo, lt}, [2]int{gt, hi})
+	}
+	return !ks.hasErrors()
+}
@@ -0,0 +1,72 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+)
+
+// inversions counts the pairs of elements of s that are out of order.
+func inversions(s SpecimenSliceSorter) int {
+	count := 0
+	for i := range s {
+		for j := i + 1; j < len(s); j++ {
+			if s[i].IntKey > s[j].IntKey {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func TestKeysortBudget(t *testing.T) {
+	const size = 10 * SPECIMEN_SIZE
+	// Enough for a few rounds of partitioning, but far from a full sort.
+	const budget = 4 * size
+	specimen := ByIntKey{GenSpecimen(size)}
+	before := inversions(specimen.SpecimenSliceSorter)
+
+	wrapped, counters := Counting(specimen)
+	if KeysortBudget(wrapped, budget) {
+		t.Errorf("Expected the sort not to complete")
+	}
+
+	if calls := counters.LessValCalls(); calls > budget {
+		t.Errorf("Expected at most %d comparisons, got %d", budget, calls)
+	}
+	if after := inversions(specimen.SpecimenSliceSorter); after >= before {
+		t.Errorf("Expected fewer than %d inversions, got %d", before, after)
+	}
+}
+
+func TestKeysortBudgetCompletes(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	if !KeysortBudget(specimen, SPECIMEN_SIZE*SPECIMEN_SIZE) {
+		t.Errorf("Expected the sort to complete")
+	}
+	if !sort.IsSorted(specimen) {
+		t.Errorf("KeysortBudget failed for ByIntKey")
+	}
+}
+
+func TestKeysortBudgetErrors(t *testing.T) {
+	specimen := ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)}
+
+	if KeysortBudget(specimen, SPECIMEN_SIZE*SPECIMEN_SIZE) {
+		t.Errorf("Expected a sort with errors not to complete")
+	}
+}
+
+func TestKeysortBudgetEqualKeys(t *testing.T) {
+	const size = 10 * SPECIMEN_SIZE
+	specimen := ByIntKey{GenSpecimen(size)}
+	for i := range specimen.SpecimenSliceSorter {
+		specimen.SpecimenSliceSorter[i].IntKey = 7
+	}
+
+	// Equal keys are settled by a single partition, which compares each
+	// element with the pivot at most twice.
+	if !KeysortBudget(specimen, 2*size) {
+		t.Errorf("Expected the sort of equal keys to complete in linear comparisons")
+	}
+}
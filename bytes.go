@@ -0,0 +1,27 @@
+package keysort
+
+import "bytes"
+
+// ByteSlices creates a keySortable over data that sorts each byte slice by the
+// key that key computes from it, compared with less. If key is nil, each slice
+// is its own key. If less is nil, keys are compared with bytes.Compare, so they
+// must be []byte.
+func ByteSlices(data [][]byte, key func(b []byte) (interface{}, error), less Comparator) *keySortable {
+	if less == nil {
+		less = func(a, b interface{}) bool {
+			return bytes.Compare(a.([]byte), b.([]byte)) < 0
+		}
+	}
+	return KeysortFunc(len(data),
+		func(i int) (interface{}, error) {
+			if key == nil {
+				return data[i], nil
+			}
+			return key(data[i])
+		},
+		less,
+		func(i, j int) {
+			data[i], data[j] = data[j], data[i]
+		},
+	)
+}
@@ -0,0 +1,43 @@
+package keysort
+
+import (
+	"bytes"
+	"testing"
+)
+
+func byteSpecimen() [][]byte {
+	return [][]byte{[]byte("pear"), []byte("fig"), []byte("apple"), []byte(""), []byte("kiwi"), []byte("banana")}
+}
+
+func TestByteSlices(t *testing.T) {
+	data := byteSpecimen()
+
+	if err := ByteSlices(data, nil, nil).Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for i := 1; i < len(data); i++ {
+		if bytes.Compare(data[i-1], data[i]) > 0 {
+			t.Errorf("Not in lexicographic order at %d: %q", i, data)
+		}
+	}
+}
+
+func TestByteSlicesByLength(t *testing.T) {
+	data := byteSpecimen()
+
+	err := ByteSlices(data,
+		func(b []byte) (interface{}, error) { return len(b), nil },
+		func(a, b interface{}) bool { return a.(int) < b.(int) },
+	).Stable()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []string{"", "fig", "pear", "kiwi", "apple", "banana"}
+	for i := range expected {
+		if string(data[i]) != expected[i] {
+			t.Errorf("Expected %q at %d, got %q", expected[i], i, data[i])
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package keysort
+
+import "sort"
+
+// CachedKeysort keeps memoized keys across many sorts of the same container.
+// This is meant to be created by calling NewCachedKeysort.
+//
+// Keys are only recomputed after they are invalidated, so the caller is
+// responsible for calling Invalidate or InvalidateAll whenever it mutates the
+// elements of the wrapped container.
+type CachedKeysort struct {
+	ks   *keySortable
+	memo *sliceMemo
+}
+
+// NewCachedKeysort creates a CachedKeysort over wrapped.
+func NewCachedKeysort(wrapped Interface) *CachedKeysort {
+	memo := newSliceMemo(wrapped.Len())
+	return &CachedKeysort{KeysortWithMemo(wrapped, memo), memo}
+}
+
+// Sort sorts the wrapped container, reusing every key that has not been
+// invalidated since it was last computed, and returns any key errors.
+func (c *CachedKeysort) Sort() error {
+	sort.Sort(c.ks)
+	return c.ks.Errors()
+}
+
+// Invalidate drops the memoized key of the element currently at index i.
+func (c *CachedKeysort) Invalidate(i int) {
+	c.ks.Lock()
+	defer c.ks.Unlock()
+	c.memo.forget(c.ks.swaps[i])
+	delete(c.ks.errors, c.ks.swaps[i])
+}
+
+// InvalidateAll drops every memoized key.
+func (c *CachedKeysort) InvalidateAll() {
+	c.ks.Lock()
+	defer c.ks.Unlock()
+	for i := range c.memo.cells {
+		c.memo.forget(i)
+	}
+	for i := range c.ks.errors {
+		delete(c.ks.errors, i)
+	}
+}
@@ -0,0 +1,73 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestCachedKeysortReusesKeys(t *testing.T) {
+	calls := make([]int, SPECIMEN_SIZE)
+	inner := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	specimen := CountedKeys{inner, calls}
+	cached := NewCachedKeysort(specimen)
+
+	if err := cached.Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// Reverse the container, so that the second sort has work to do.
+	for i, j := 0, SPECIMEN_SIZE-1; i < j; i, j = i+1, j-1 {
+		cached.ks.Swap(i, j)
+	}
+	for i := range calls {
+		calls[i] = 0
+	}
+
+	if err := cached.Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !sort.IsSorted(inner) {
+		t.Errorf("Second sort failed")
+	}
+	if total := sumInts(calls); total != 0 {
+		t.Errorf("Expected no Key calls on the second sort, got %d", total)
+	}
+}
+
+func TestCachedKeysortInvalidate(t *testing.T) {
+	calls := make([]int, SPECIMEN_SIZE)
+	inner := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	cached := NewCachedKeysort(CountedKeys{inner, calls})
+	cached.Sort()
+
+	// Change the smallest element so it becomes the largest.
+	inner.SpecimenSliceSorter[0].IntKey = SPECIMEN_SIZE
+	cached.Invalidate(0)
+	for i := range calls {
+		calls[i] = 0
+	}
+
+	cached.Sort()
+	if !sort.IsSorted(inner) {
+		t.Errorf("Sort after Invalidate failed")
+	}
+	if total := sumInts(calls); total != 1 {
+		t.Errorf("Expected exactly one Key call after Invalidate, got %d", total)
+	}
+
+	cached.InvalidateAll()
+	for i := range calls {
+		calls[i] = 0
+	}
+	cached.Sort()
+	if total := sumInts(calls); total != SPECIMEN_SIZE {
+		t.Errorf("Expected %d Key calls after InvalidateAll, got %d", SPECIMEN_SIZE, total)
+	}
+}
+
+func sumInts(ints []int) (total int) {
+	for _, i := range ints {
+		total += i
+	}
+	return
+}
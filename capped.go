@@ -0,0 +1,89 @@
+package keysort
+
+import "container/list"
+
+// lruMemo is a Memo that holds at most maxEntries keys, forgetting the least
+// recently used key to make room for a new one.
+type lruMemo struct {
+	maxEntries int
+	// order holds the original indices of the memoized keys, most recently
+	// used first.
+	order *list.List
+	// entries maps each memoized original index to its element of order.
+	entries   map[int]*list.Element
+	evictions int
+}
+
+// lruEntry is the value of each element of lruMemo.order.
+type lruEntry struct {
+	origIndex int
+	value     interface{}
+}
+
+// newLRUMemo returns an empty lruMemo with room for maxEntries keys.
+func newLRUMemo(maxEntries int) *lruMemo {
+	return &lruMemo{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[int]*list.Element, maxEntries),
+	}
+}
+
+// Get returns the value memoized for origIndex, if it has not been evicted,
+// and marks it as recently used.
+func (m *lruMemo) Get(origIndex int) (interface{}, bool) {
+	element, ok := m.entries[origIndex]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(element)
+	return element.Value.(lruEntry).value, true
+}
+
+// Put memoizes value for origIndex, evicting the least recently used key if
+// the memo is full.
+func (m *lruMemo) Put(origIndex int, value interface{}) {
+	if element, ok := m.entries[origIndex]; ok {
+		element.Value = lruEntry{origIndex, value}
+		m.order.MoveToFront(element)
+		return
+	}
+	if m.order.Len() >= m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(lruEntry).origIndex)
+		m.evictions++
+	}
+	m.entries[origIndex] = m.order.PushFront(lruEntry{origIndex, value})
+}
+
+// Reset forgets every memoized key, and the count of evictions.
+func (m *lruMemo) Reset() {
+	m.order.Init()
+	clear(m.entries)
+	m.evictions = 0
+}
+
+// KeysortCapped is like Keysort, but memoizes at most maxEntries keys at a
+// time, evicting the least recently used. An evicted key is recomputed the
+// next time it is needed, so KeyCalls() may exceed Len(): this trades the
+// guarantee that each key is computed once for bounded memory. It panics if
+// maxEntries is less than one.
+func KeysortCapped(wrapped Interface, maxEntries int) *keySortable {
+	if maxEntries < 1 {
+		panic("keysort: KeysortCapped needs a positive maxEntries")
+	}
+	return KeysortWithMemo(wrapped, newLRUMemo(maxEntries))
+}
+
+// EvictionCount returns how many keys have been evicted from the memo of a
+// keySortable created by KeysortCapped. It is always zero for any other
+// keySortable.
+func (ks *keySortable) EvictionCount() int {
+	ks.Lock()
+	defer ks.Unlock()
+	if memo, ok := ks.memo.(*lruMemo); ok {
+		return memo.evictions
+	}
+	return 0
+}
@@ -0,0 +1,66 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestKeysortCapped(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	ks := KeysortCapped(specimen, SPECIMEN_SIZE/4)
+	if err := ks.Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !sort.IsSorted(specimen) {
+		t.Errorf("KeysortCapped failed for ByIntKey")
+	}
+	if ks.EvictionCount() == 0 {
+		t.Errorf("Expected some keys to be evicted")
+	}
+	if ks.KeyCalls() <= SPECIMEN_SIZE {
+		t.Errorf("Expected evicted keys to be recomputed, got %d calls", ks.KeyCalls())
+	}
+	if memoized := countMemoized(ks); memoized > SPECIMEN_SIZE/4 {
+		t.Errorf("Expected at most %d memoized keys, got %d", SPECIMEN_SIZE/4, memoized)
+	}
+}
+
+func TestKeysortCappedLarge(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	ks := KeysortCapped(specimen, SPECIMEN_SIZE)
+	sort.Sort(ks)
+
+	if ks.EvictionCount() != 0 {
+		t.Errorf("Expected no evictions, got %d", ks.EvictionCount())
+	}
+	if ks.KeyCalls() != SPECIMEN_SIZE {
+		t.Errorf("Expected %d Key calls, got %d", SPECIMEN_SIZE, ks.KeyCalls())
+	}
+	if Keysort(specimen).EvictionCount() != 0 {
+		t.Errorf("Expected no evictions from the built-in memo")
+	}
+}
+
+func TestLRUMemo(t *testing.T) {
+	memo := newLRUMemo(2)
+	memo.Put(0, "a")
+	memo.Put(1, "b")
+	// Use 0, so that 1 is the least recently used.
+	memo.Get(0)
+	memo.Put(2, "c")
+
+	if _, ok := memo.Get(1); ok {
+		t.Errorf("Expected 1 to be evicted")
+	}
+	for _, i := range []int{0, 2} {
+		if _, ok := memo.Get(i); !ok {
+			t.Errorf("Expected %d to be memoized", i)
+		}
+	}
+	if memo.evictions != 1 {
+		t.Errorf("Expected 1 eviction, got %d", memo.evictions)
+	}
+}
@@ -0,0 +1,43 @@
+package keysort
+
+import "fmt"
+
+// OrderingViolationError is recorded when LessVal reported that each of two
+// keys is less than the other, so it is not a strict weak ordering. I and J are
+// the original indices of the elements, and A and B are their keys. The error
+// is recorded against I.
+type OrderingViolationError struct {
+	I, J int
+	A, B interface{}
+}
+
+// Error returns a string representation of this error.
+func (e OrderingViolationError) Error() string {
+	return fmt.Sprintf("keysort: LessVal orders the keys of %d and %d both ways: %v, %v", e.I, e.J, e.A, e.B)
+}
+
+// KeysortChecked is like Keysort, but checks every comparison that LessVal
+// reports as less by also comparing the keys the other way around. If both are
+// less, an OrderingViolationError is recorded, which is then returned by
+// Errors(). This doubles the calls to LessVal, so it is meant for debugging a
+// comparator that gives wrong results.
+func KeysortChecked(wrapped Interface) *keySortable {
+	ks := Keysort(wrapped)
+	ks.checked = true
+	return ks
+}
+
+// checkOrdering is called when the key a of the element currently at i was
+// found less than the key b of the element at j. It reports whether b is not
+// also less than a, recording an OrderingViolationError if it is.
+func (ks *keySortable) checkOrdering(i, j int, a, b interface{}) bool {
+	greater, ok := ks.lessVal(j, i, b, a)
+	if !ok {
+		return false
+	}
+	if greater {
+		ks.recordError(ks.swaps[i], OrderingViolationError{ks.swaps[i], ks.swaps[j], a, b})
+		return false
+	}
+	return true
+}
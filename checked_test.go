@@ -0,0 +1,48 @@
+package keysort
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+// ByIntKeyInconsistent claims that 0 and 1 are each less than the other.
+type ByIntKeyInconsistent struct{ ByIntKey }
+
+func (s ByIntKeyInconsistent) LessVal(i, j interface{}) bool {
+	a, b := i.(int), j.(int)
+	if a+b == 1 {
+		return true
+	}
+	return a < b
+}
+
+func TestKeysortChecked(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	ks := KeysortChecked(specimen)
+	sort.Sort(ks)
+
+	if err := ks.Errors(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !sort.IsSorted(specimen) {
+		t.Errorf("KeysortChecked failed for ByIntKey")
+	}
+}
+
+func TestKeysortCheckedViolation(t *testing.T) {
+	// The elements at 0 and 1 have IntKeys 1 and 0.
+	specimen := ByIntKeyInconsistent{ByIntKey{GenSpecimen(SPECIMEN_SIZE)}}
+
+	ks := KeysortChecked(specimen)
+	sort.Sort(ks)
+
+	var violation OrderingViolationError
+	if !errors.As(ks.Errors(), &violation) {
+		t.Fatalf("Expected an OrderingViolationError, got %v", ks.Errors())
+	}
+	if violation.A.(int)+violation.B.(int) != 1 {
+		t.Errorf("Expected the keys 0 and 1 in %v", violation)
+	}
+}
@@ -0,0 +1,50 @@
+package keysort
+
+// A Cmp compares the keys a and b, returning a negative number if a sorts
+// before b, a positive number if a sorts after b, and zero if they are equal,
+// in the manner of slices.SortFunc.
+type Cmp func(a, b interface{}) int
+
+// CmpValer may optionally be implemented by an Interface that can compare keys
+// three ways. Composite uses it to tell equal sub-keys apart from ordered ones
+// with a single comparison instead of two, and a keySortable uses it to settle
+// each comparison, including ties, with a single call.
+type CmpValer interface {
+	CmpVal(a, b interface{}) int
+}
+
+// withCmp makes an Interface from a KeyProvider and a Cmp.
+type withCmp struct {
+	KeyProvider
+	cmp Cmp
+}
+
+// LessVal reports whether the Cmp orders i before j.
+func (w withCmp) LessVal(i, j interface{}) bool {
+	return w.cmp(i, j) < 0
+}
+
+// CmpVal delegates to the Cmp.
+func (w withCmp) CmpVal(i, j interface{}) int {
+	return w.cmp(i, j)
+}
+
+// KeysortCmp creates a keySortable that sorts the keys of wrapped using the
+// three-way comparison cmp.
+func KeysortCmp(wrapped KeyProvider, cmp Cmp) *keySortable {
+	return Keysort(CmpInterface(wrapped, cmp))
+}
+
+// KeysortStableCmp sorts wrapped by its keys using the three-way comparison
+// cmp, in the manner of slices.SortStableFunc: elements whose keys cmp finds
+// equal keep their original order. Each key is computed once, however many
+// times cmp is called on it.
+func KeysortStableCmp(wrapped KeyProvider, cmp Cmp) error {
+	return KeysortDeterministic(CmpInterface(wrapped, cmp)).Sort()
+}
+
+// CmpInterface makes an Interface from wrapped and cmp, for use where an
+// Interface is needed, such as with Composite.
+func CmpInterface(wrapped KeyProvider, cmp Cmp) Interface {
+	return withCmp{wrapped, cmp}
+}
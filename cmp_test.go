@@ -0,0 +1,134 @@
+package keysort
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func compareInts(a, b interface{}) int {
+	return a.(int) - b.(int)
+}
+
+func TestKeysortCmp(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	expected := append(SpecimenSliceSorter{}, specimen...)
+
+	sort.Sort(KeysortCmp(ByIntKey{specimen}, compareInts))
+	sort.Sort(KeysortWith(ByIntKey{expected}, func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	}))
+
+	for i := range specimen {
+		if specimen[i].IntKey != expected[i].IntKey {
+			t.Errorf("KeysortCmp and KeysortWith disagree at %d: %d != %d",
+				i, specimen[i].IntKey, expected[i].IntKey)
+		}
+	}
+}
+
+func TestCompositeCmpComparisons(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	for i := range specimen {
+		specimen[i].IntKey = rand.Intn(3)
+	}
+	lessSpecimen := append(SpecimenSliceSorter{}, specimen...)
+	cmpSpecimen := append(SpecimenSliceSorter{}, specimen...)
+
+	lessCalls, cmpCalls := 0, 0
+	byIntLess := withComparator{ByIntKey{lessSpecimen}, func(a, b interface{}) bool {
+		lessCalls++
+		return a.(int) < b.(int)
+	}}
+	byIntCmp := CmpInterface(ByIntKey{cmpSpecimen}, func(a, b interface{}) int {
+		cmpCalls++
+		return compareInts(a, b)
+	})
+
+	sort.Stable(Keysort(Composite(byIntLess, ByStringKey{lessSpecimen})))
+	sort.Stable(Keysort(Composite(byIntCmp, ByStringKey{cmpSpecimen})))
+
+	for i := range specimen {
+		if lessSpecimen[i] != cmpSpecimen[i] {
+			t.Errorf("Less and Cmp composites disagree at %d", i)
+		}
+		if i > 0 {
+			prev, cur := cmpSpecimen[i-1], cmpSpecimen[i]
+			if prev.IntKey > cur.IntKey ||
+				prev.IntKey == cur.IntKey && strings.Compare(prev.StringKey, cur.StringKey) > 0 {
+				t.Errorf("Cmp composite not sorted at %d", i)
+			}
+		}
+	}
+	if cmpCalls >= lessCalls {
+		t.Errorf("Expected fewer Cmp calls than Less calls, got %d and %d", cmpCalls, lessCalls)
+	}
+}
+
+func TestKeysortStableCmp(t *testing.T) {
+	specimen := GenSpecimen(50 * SPECIMEN_SIZE)
+	for i := range specimen {
+		// Use NotKey to remember the input order, and force duplicate keys.
+		specimen[i].NotKey = i
+		specimen[i].IntKey = rand.Intn(3)
+	}
+
+	if err := KeysortStableCmp(ByIntKey{specimen}, compareInts); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for i := 1; i < len(specimen); i++ {
+		prev, cur := specimen[i-1], specimen[i]
+		if prev.IntKey > cur.IntKey {
+			t.Errorf("Not sorted at %d", i)
+		}
+		if prev.IntKey == cur.IntKey && prev.NotKey > cur.NotKey {
+			t.Errorf("Equal keys not in original order at %d", i)
+		}
+	}
+}
+
+func TestKeysortStableCmpMemoized(t *testing.T) {
+	var keyCalls int64
+	cmpCalls := 0
+	specimen := ByIntKeyCounted{GenSpecimen(SPECIMEN_SIZE), &keyCalls}
+
+	err := KeysortStableCmp(specimen, func(a, b interface{}) int {
+		cmpCalls++
+		return compareInts(a, b)
+	})
+
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !sort.IsSorted(ByIntKey{specimen.SpecimenSliceSorter}) {
+		t.Errorf("KeysortStableCmp failed for ByIntKeyCounted")
+	}
+	if keyCalls != SPECIMEN_SIZE {
+		t.Errorf("Expected %d key calls, got %d", SPECIMEN_SIZE, keyCalls)
+	}
+	if cmpCalls <= SPECIMEN_SIZE {
+		t.Errorf("Expected more comparisons than keys, got %d", cmpCalls)
+	}
+}
+
+func TestKeysortStableCmpSingleCall(t *testing.T) {
+	specimen := GenSpecimen(8)
+	for i := range specimen {
+		specimen[i].IntKey = 7
+	}
+	cmpCalls := 0
+	cmp := func(a, b interface{}) int {
+		cmpCalls++
+		return compareInts(a, b)
+	}
+
+	ks := KeysortDeterministic(CmpInterface(ByIntKey{specimen}, cmp))
+	if err := ks.Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if cmpCalls != ks.Comparisons() {
+		t.Errorf("Expected one Cmp call per comparison, got %d for %d", cmpCalls, ks.Comparisons())
+	}
+}
@@ -0,0 +1,50 @@
+package keysort
+
+import "fmt"
+
+// A Collator compares strings according to the rules of some locale. It is
+// satisfied by *collate.Collator from golang.org/x/text/collate, which this
+// package does not depend on directly.
+type Collator interface {
+	CompareString(a, b string) int
+}
+
+// collateKeys wraps a KeyProvider whose keys are strings.
+type collateKeys struct {
+	KeyProvider
+	collator Collator
+}
+
+// Key checks that the wrapped key is a string.
+func (c collateKeys) Key(i int) (interface{}, error) {
+	value, err := c.KeyProvider.Key(i)
+	if err != nil {
+		return value, err
+	}
+	if _, ok := value.(string); !ok {
+		return value, fmt.Errorf("keysort: Key(%d) is a %T, not a string", i, value)
+	}
+	return value, nil
+}
+
+// LessVal reports whether the collator orders i before j.
+func (c collateKeys) LessVal(i, j interface{}) bool {
+	return c.CmpVal(i, j) < 0
+}
+
+// CmpVal delegates to the collator.
+func (c collateKeys) CmpVal(i, j interface{}) int {
+	return c.collator.CompareString(i.(string), j.(string))
+}
+
+// KeysortCollate creates a keySortable over wrapped, whose keys must be
+// strings, that sorts them with collator rather than by byte order, so that
+// accented and other non-ASCII characters sort as a reader of the collator's
+// locale expects. A key that is not a string is recorded as an error.
+//
+// collator is typically made with collate.New(language.French), say. Note
+// that a *collate.Collator is not safe for concurrent use, so it must not be
+// shared with ParallelSort, or with other sorts running at the same time.
+func KeysortCollate(wrapped KeyProvider, collator Collator) *keySortable {
+	return Keysort(collateKeys{wrapped, collator})
+}
@@ -0,0 +1,58 @@
+package keysort
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// foldingCollator stands in for a locale's collator, ordering accented
+// letters with their base letters, and only then by byte order.
+type foldingCollator struct{}
+
+var foldAccents = strings.NewReplacer("é", "e", "è", "e", "á", "a", "ç", "c", "ô", "o")
+
+func (foldingCollator) CompareString(a, b string) int {
+	if c := strings.Compare(foldAccents.Replace(a), foldAccents.Replace(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// ByString sorts by its own strings.
+type ByString []string
+
+func (s ByString) Key(i int) (interface{}, error) { return s[i], nil }
+func (s ByString) Swap(i, j int)                  { s[i], s[j] = s[j], s[i] }
+func (s ByString) Len() int                       { return len(s) }
+
+func TestKeysortCollate(t *testing.T) {
+	words := []string{"zèbre", "éclair", "côte", "apple", "cote", "banane"}
+
+	byteOrder := append([]string{}, words...)
+	sort.Strings(byteOrder)
+
+	specimen := append(ByString{}, words...)
+	if err := KeysortCollate(specimen, foldingCollator{}).Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []string{"apple", "banane", "cote", "côte", "éclair", "zèbre"}
+	for i := range expected {
+		if specimen[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, specimen)
+			break
+		}
+	}
+	if strings.Join(byteOrder, " ") == strings.Join(expected, " ") {
+		t.Errorf("Expected collated order to differ from byte order %v", byteOrder)
+	}
+}
+
+func TestKeysortCollateNotString(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	if err := KeysortCollate(specimen, foldingCollator{}).Sort(); err == nil {
+		t.Errorf("Expected an error for keys that are not strings")
+	}
+}
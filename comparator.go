@@ -0,0 +1,29 @@
+package keysort
+
+// A Comparator reports whether the key a sorts before the key b.
+type Comparator func(a, b interface{}) bool
+
+// KeyProvider is the part of Interface that extracts keys from a container,
+// without saying how the keys are ordered.
+type KeyProvider interface {
+	Key(i int) (interface{}, error)
+	Swap(i, j int)
+	Len() int
+}
+
+// withComparator makes an Interface from a KeyProvider and a Comparator.
+type withComparator struct {
+	KeyProvider
+	cmp Comparator
+}
+
+// LessVal delegates to the Comparator.
+func (w withComparator) LessVal(i, j interface{}) bool {
+	return w.cmp(i, j)
+}
+
+// KeysortWith creates a keySortable that sorts the keys of wrapped using cmp,
+// so that one way of extracting keys can be reused with many orderings.
+func KeysortWith(wrapped KeyProvider, cmp Comparator) *keySortable {
+	return Keysort(withComparator{wrapped, cmp})
+}
@@ -0,0 +1,28 @@
+package keysort
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ByLength extracts the length of each string as its key.
+type ByLength []string
+
+func (s ByLength) Key(i int) (interface{}, error) { return len(s[i]), nil }
+func (s ByLength) Swap(i, j int)                  { s[i], s[j] = s[j], s[i] }
+func (s ByLength) Len() int                       { return len(s) }
+
+func ExampleKeysortWith() {
+	words := ByLength{"ccc", "a", "dddd", "bb"}
+	ascending := func(a, b interface{}) bool { return a.(int) < b.(int) }
+	descending := func(a, b interface{}) bool { return a.(int) > b.(int) }
+
+	sort.Sort(KeysortWith(words, ascending))
+	fmt.Println(words)
+
+	sort.Sort(KeysortWith(words, descending))
+	fmt.Println(words)
+	// Output:
+	// [a bb ccc dddd]
+	// [dddd ccc bb a]
+}
@@ -0,0 +1,65 @@
+package keysort
+
+// composite combines several Interfaces into one that sorts by each of their
+// keys in turn.
+type composite []Interface
+
+// Composite returns an Interface whose key is a []interface{} of the keys of
+// each Interface in keys, compared lexicographically: later keys are only
+// consulted to break ties between earlier ones.
+//
+// All of keys must be views over the same container. Swap and Len are
+// delegated to the first of them only, so that elements are swapped once.
+// Composite panics if no keys are given.
+func Composite(keys ...Interface) Interface {
+	if len(keys) == 0 {
+		panic("keysort: Composite needs at least one Interface")
+	}
+	return composite(keys)
+}
+
+// Key returns the keys of every wrapped Interface at index i, stopping at the
+// first one that fails.
+func (c composite) Key(i int) (interface{}, error) {
+	values := make([]interface{}, len(c))
+	for n, wrapped := range c {
+		value, err := wrapped.Key(i)
+		if err != nil {
+			return nil, err
+		}
+		values[n] = value
+	}
+	return values, nil
+}
+
+// LessVal compares two composite keys lexicographically, short-circuiting at
+// the first sub-key that is not equal. Sub-keys whose Interface implements
+// CmpValer are compared once; others need up to two calls to LessVal.
+func (c composite) LessVal(i, j interface{}) bool {
+	iValues, jValues := i.([]interface{}), j.([]interface{})
+	for n, wrapped := range c {
+		if cmp, ok := wrapped.(CmpValer); ok {
+			if result := cmp.CmpVal(iValues[n], jValues[n]); result != 0 {
+				return result < 0
+			}
+			continue
+		}
+		if wrapped.LessVal(iValues[n], jValues[n]) {
+			return true
+		}
+		if wrapped.LessVal(jValues[n], iValues[n]) {
+			return false
+		}
+	}
+	return false
+}
+
+// Swap delegates to the first wrapped Interface.
+func (c composite) Swap(i, j int) {
+	c[0].Swap(i, j)
+}
+
+// Len delegates to the first wrapped Interface.
+func (c composite) Len() int {
+	return c[0].Len()
+}
@@ -0,0 +1,52 @@
+package keysort
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestCompositeTiebreaker(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	for i := range specimen {
+		// Force plenty of ties on IntKey.
+		specimen[i].IntKey = rand.Intn(3)
+	}
+	intCalls := make([]int, SPECIMEN_SIZE)
+	stringCalls := make([]int, SPECIMEN_SIZE)
+	byInt := CountedKeys{ByIntKey{specimen}, intCalls}
+	byString := CountedKeys{ByStringKey{specimen}, stringCalls}
+
+	sort.Sort(Keysort(Composite(byInt, Reverse(byString))))
+
+	for i := 1; i < len(specimen); i++ {
+		prev, cur := specimen[i-1], specimen[i]
+		if prev.IntKey > cur.IntKey {
+			t.Errorf("Not sorted by IntKey at %d", i)
+		}
+		if prev.IntKey == cur.IntKey && prev.StringKey < cur.StringKey {
+			t.Errorf("Ties not broken by descending StringKey at %d", i)
+		}
+	}
+
+	intTotal, stringTotal := 0, 0
+	for i := range intCalls {
+		intTotal += intCalls[i]
+		stringTotal += stringCalls[i]
+	}
+	if intTotal > SPECIMEN_SIZE || stringTotal > SPECIMEN_SIZE {
+		t.Errorf("Sub-keys called %d and %d times, expected at most %d each",
+			intTotal, stringTotal, SPECIMEN_SIZE)
+	}
+}
+
+// CountedKeys counts calls to Key() at each position in calls.
+type CountedKeys struct {
+	Interface
+	calls []int
+}
+
+func (c CountedKeys) Key(i int) (interface{}, error) {
+	c.calls[i]++
+	return c.Interface.Key(i)
+}
@@ -0,0 +1,36 @@
+package keysort
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SortedCopy returns a sorted copy of slice, leaving slice itself unchanged.
+// key computes the key of the element at index i of slice, and less compares
+// two keys, as for KeysortFunc. The copy is returned even if some keys failed,
+// along with the errors, but its order cannot then be trusted. An error is also
+// returned if slice is not a slice.
+func SortedCopy(slice interface{}, key func(i int) (interface{}, error), less Comparator) (interface{}, error) {
+	value := reflect.ValueOf(slice)
+	if value.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("keysort: SortedCopy needs a slice, got %T", slice)
+	}
+
+	// Sort the original indices rather than the elements, so that key always
+	// sees the index of an element in slice.
+	perm := make([]int, value.Len())
+	for i := range perm {
+		perm[i] = i
+	}
+	err := KeysortFunc(len(perm),
+		func(i int) (interface{}, error) { return key(perm[i]) },
+		less,
+		func(i, j int) { perm[i], perm[j] = perm[j], perm[i] },
+	).Sort()
+
+	sorted := reflect.MakeSlice(value.Type(), len(perm), len(perm))
+	for i, original := range perm {
+		sorted.Index(i).Set(value.Index(original))
+	}
+	return sorted.Interface(), err
+}
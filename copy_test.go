@@ -0,0 +1,50 @@
+package keysort
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestSortedCopy(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	original := append(SpecimenSliceSorter{}, specimen...)
+
+	sorted, err := SortedCopy(specimen,
+		func(i int) (interface{}, error) { return specimen[i].IntKey, nil },
+		func(a, b interface{}) bool { return a.(int) < b.(int) })
+
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	for i := range original {
+		if specimen[i] != original[i] {
+			t.Errorf("Expected the input to be unchanged at %d", i)
+			break
+		}
+	}
+	result, ok := sorted.([]ExampleToSort)
+	if !ok {
+		t.Fatalf("Expected a []ExampleToSort, got %T", sorted)
+	}
+	if len(result) != SPECIMEN_SIZE || !sort.IsSorted(ByIntKey{result}) {
+		t.Errorf("SortedCopy failed to sort by IntKey")
+	}
+}
+
+func TestSortedCopyErrors(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+
+	_, err := SortedCopy(specimen,
+		func(i int) (interface{}, error) {
+			if i == 3 {
+				return nil, fmt.Errorf("Blah")
+			}
+			return specimen[i].IntKey, nil
+		},
+		func(a, b interface{}) bool { return a.(int) < b.(int) })
+
+	if err == nil {
+		t.Errorf("Errors were expected.")
+	}
+}
@@ -0,0 +1,59 @@
+package keysort
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Counters counts the calls made to an Interface wrapped by Counting. It is
+// safe to read while the Interface is in use.
+type Counters struct {
+	keyCalls, swapCalls, lessValCalls int64
+}
+
+// KeyCalls returns how many times Key, or KeyCtx, has been called.
+func (c *Counters) KeyCalls() int { return int(atomic.LoadInt64(&c.keyCalls)) }
+
+// SwapCalls returns how many times Swap has been called.
+func (c *Counters) SwapCalls() int { return int(atomic.LoadInt64(&c.swapCalls)) }
+
+// LessValCalls returns how many times LessVal has been called.
+func (c *Counters) LessValCalls() int { return int(atomic.LoadInt64(&c.lessValCalls)) }
+
+// counting wraps an Interface, counting calls to it.
+type counting struct {
+	Interface
+	counters *Counters
+}
+
+// Counting returns an Interface that delegates to wrapped, counting the calls
+// made to its Key, Swap and LessVal methods in the returned Counters. This is
+// useful for profiling how a sort uses wrapped.
+func Counting(wrapped Interface) (Interface, *Counters) {
+	counters := &Counters{}
+	return counting{wrapped, counters}, counters
+}
+
+func (c counting) Key(i int) (interface{}, error) {
+	atomic.AddInt64(&c.counters.keyCalls, 1)
+	return c.Interface.Key(i)
+}
+
+// KeyCtx counts as a call to Key, and passes ctx on to the wrapped KeyCtx,
+// if there is one.
+func (c counting) KeyCtx(ctx context.Context, i int) (interface{}, error) {
+	atomic.AddInt64(&c.counters.keyCalls, 1)
+	return keyCtx(ctx, c.Interface, i)
+}
+
+func (c counting) inner() Interface { return c.Interface }
+
+func (c counting) Swap(i, j int) {
+	atomic.AddInt64(&c.counters.swapCalls, 1)
+	c.Interface.Swap(i, j)
+}
+
+func (c counting) LessVal(i, j interface{}) bool {
+	atomic.AddInt64(&c.counters.lessValCalls, 1)
+	return c.Interface.LessVal(i, j)
+}
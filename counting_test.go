@@ -0,0 +1,42 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+)
+
+// CountedSort counts the calls to Less and Swap of a sort.Interface.
+type CountedSort struct {
+	sort.Interface
+	less, swaps int
+}
+
+func (c *CountedSort) Less(i, j int) bool {
+	c.less++
+	return c.Interface.Less(i, j)
+}
+
+func (c *CountedSort) Swap(i, j int) {
+	c.swaps++
+	c.Interface.Swap(i, j)
+}
+
+func TestCounting(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	// Sorting an identical copy directly makes the same comparisons and swaps.
+	expected := &CountedSort{Interface: ByIntKey{append(SpecimenSliceSorter{}, specimen...)}}
+	sort.Sort(expected)
+
+	wrapped, counters := Counting(ByIntKey{specimen})
+	sort.Sort(Keysort(wrapped))
+
+	if counters.KeyCalls() != SPECIMEN_SIZE {
+		t.Errorf("Expected %d Key calls, got %d", SPECIMEN_SIZE, counters.KeyCalls())
+	}
+	if counters.LessValCalls() != expected.less {
+		t.Errorf("Expected %d LessVal calls, got %d", expected.less, counters.LessValCalls())
+	}
+	if counters.SwapCalls() != expected.swaps {
+		t.Errorf("Expected %d Swap calls, got %d", expected.swaps, counters.SwapCalls())
+	}
+}
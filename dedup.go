@@ -0,0 +1,56 @@
+package keysort
+
+import (
+	"context"
+	"sync"
+)
+
+// sharedKey is the result of computing the key for one resource, shared by
+// every index that maps to that resource.
+type sharedKey struct {
+	// done is closed once value and err are set.
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// PrimedKeysortDeduped is like PrimedKeysort, but computes only one key for
+// all the indices that resourceKey maps to the same string: the first of them
+// to be primed calls Key, and the others wait for it and memoize the same
+// result, error and all. This avoids a thundering herd of calls for the same
+// expensive resource. Keys computed later, while sorting, are not deduplicated.
+//
+// resourceKey is called with the index of each element, and may be called from
+// several goroutines at once.
+func PrimedKeysortDeduped(wrapped Interface, parallelism int, resourceKey func(i int) string) *keySortable {
+	ks := Keysort(wrapped)
+
+	var lock sync.Mutex
+	shared := map[string]*sharedKey{}
+
+	ks.memoizeWith(context.Background(), parallelism, ks.allIndexes, func(i int) {
+		resource := resourceKey(i)
+		lock.Lock()
+		result, ok := shared[resource]
+		if !ok {
+			result = &sharedKey{done: make(chan struct{})}
+			shared[resource] = result
+		}
+		lock.Unlock()
+
+		if ok {
+			<-result.done
+		} else {
+			result.value, result.err = ks.callKey(i)
+			close(result.done)
+		}
+
+		ks.Lock()
+		defer ks.Unlock()
+		if _, ok := ks.lookupKey(i); ok {
+			return
+		}
+		ks.storeKey(i, result.value, result.err)
+	})
+	return ks
+}
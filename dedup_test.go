@@ -0,0 +1,56 @@
+package keysort
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ByResource computes each key from a shared resource, recording how many
+// times each resource was fetched.
+type ByResource struct {
+	SpecimenSliceSorter
+	lock    *sync.Mutex
+	fetches map[int]int
+}
+
+func (s ByResource) LessVal(i, j interface{}) bool {
+	return i.(int) < j.(int)
+}
+
+func (s ByResource) Key(i int) (interface{}, error) {
+	resource := s.At(i).IntKey
+	s.lock.Lock()
+	s.fetches[resource]++
+	s.lock.Unlock()
+	// Be slow, so that other indices for this resource are primed meanwhile.
+	time.Sleep(time.Millisecond)
+	return resource, nil
+}
+
+func TestPrimedKeysortDeduped(t *testing.T) {
+	specimen := ByResource{GenSpecimen(SPECIMEN_SIZE), &sync.Mutex{}, map[int]int{}}
+	for i := range specimen.SpecimenSliceSorter {
+		// Most elements share a single resource.
+		if i%4 != 0 {
+			specimen.SpecimenSliceSorter[i].IntKey = 7
+		}
+	}
+
+	ks := PrimedKeysortDeduped(specimen, 8, func(i int) string {
+		return strconv.Itoa(specimen.At(i).IntKey)
+	})
+
+	if fetches := specimen.fetches[7]; fetches != 1 {
+		t.Errorf("Expected the shared resource to be fetched once, got %d", fetches)
+	}
+	if memoized := countMemoized(ks); memoized != SPECIMEN_SIZE {
+		t.Errorf("Expected %d memoized keys, got %d", SPECIMEN_SIZE, memoized)
+	}
+	sort.Sort(ks)
+	if !sort.IsSorted(ByIntKey{specimen.SpecimenSliceSorter}) {
+		t.Errorf("PrimedKeysortDeduped failed to sort")
+	}
+}
@@ -76,6 +76,6 @@ func BenchmarkSortFunc(factory func() sort.Interface) {
 
 func main() {
 	BenchmarkSortFunc(func() sort.Interface { return ByHiddenValue(ExampleHardToSortSlice()) })
-	BenchmarkSortFunc(func() sort.Interface { return keysort.Keysort(ByHiddenValue(ExampleHardToSortSlice())) })
-	BenchmarkSortFunc(func() sort.Interface { return keysort.PrimedKeysort(ByHiddenValue(ExampleHardToSortSlice()), 0) })
+	BenchmarkSortFunc(func() sort.Interface { return keysort.By(ByHiddenValue(ExampleHardToSortSlice())) })
+	BenchmarkSortFunc(func() sort.Interface { return keysort.PrimedBy(ByHiddenValue(ExampleHardToSortSlice()), 0) })
 }
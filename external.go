@@ -0,0 +1,331 @@
+package keysort
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// A Record is a single opaque item handled by ExternalKeysort.
+type Record []byte
+
+// A RecordSource yields Records one at a time. Next returns io.EOF once there
+// are no more Records.
+type RecordSource interface {
+	Next() (Record, error)
+}
+
+// A KeyCodec writes the keys of Records to the run files spilled by
+// ExternalKeysortCodec, and reads them back for the merge.
+type KeyCodec interface {
+	EncodeKey(key interface{}) ([]byte, error)
+	DecodeKey(data []byte) (interface{}, error)
+}
+
+// ExternalKeysort sorts the Records from records by the key keyFn computes for
+// each, even if there are too many to fit in memory. It reads up to
+// maxInMemory Records at a time, keysorts them, and spills each sorted run to a
+// temporary file. The returned RecordSource merges the runs.
+//
+// keyFn is called once per Record while its run is sorted, and once more when
+// the Record is read back for the merge, since keys are not written to disk.
+// ExternalKeysortCodec avoids the second call.
+//
+// The returned RecordSource also implements io.Closer. Temporary files are
+// removed once it returns io.EOF or an error, or when it is closed, whichever
+// happens first.
+func ExternalKeysort(records RecordSource, keyFn func(Record) (interface{}, error), less Comparator, maxInMemory int) (RecordSource, error) {
+	return ExternalKeysortCodec(records, keyFn, nil, less, maxInMemory)
+}
+
+// ExternalKeysortCodec is like ExternalKeysort, but writes each key to the run
+// file alongside its Record with codec, and decodes it again when the Record is
+// read back for the merge, so that keyFn is called only once per Record. If
+// codec is nil, keys are computed again instead, as by ExternalKeysort.
+func ExternalKeysortCodec(records RecordSource, keyFn func(Record) (interface{}, error), codec KeyCodec, less Comparator, maxInMemory int) (RecordSource, error) {
+	if maxInMemory < 1 {
+		maxInMemory = 1
+	}
+
+	merged := &mergedSource{keyFn: keyFn, codec: codec, less: less}
+	for {
+		chunk, readErr := readChunk(records, maxInMemory)
+		if readErr != nil && readErr != io.EOF {
+			merged.Close()
+			return nil, readErr
+		}
+
+		keys, err := sortRecords(chunk, keyFn, less)
+		if err != nil {
+			merged.Close()
+			return nil, err
+		}
+
+		// If everything fits in memory, there is no need to spill to disk.
+		if readErr == io.EOF && merged.dir == "" {
+			return &sliceSource{records: chunk}, nil
+		}
+		if len(chunk) > 0 {
+			if err := merged.spill(chunk, keys); err != nil {
+				merged.Close()
+				return nil, err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if err := merged.start(); err != nil {
+		merged.Close()
+		return nil, err
+	}
+	return merged, nil
+}
+
+// readChunk reads up to max Records from records. It returns io.EOF, along with
+// the Records read so far, once records is exhausted.
+func readChunk(records RecordSource, max int) ([]Record, error) {
+	chunk := make([]Record, 0, max)
+	for len(chunk) < max {
+		record, err := records.Next()
+		if err != nil {
+			return chunk, err
+		}
+		chunk = append(chunk, record)
+	}
+	return chunk, nil
+}
+
+// sortRecords keysorts chunk in place, returning the key of each Record in
+// the sorted order.
+func sortRecords(chunk []Record, keyFn func(Record) (interface{}, error), less Comparator) ([]interface{}, error) {
+	ks := KeysortFunc(len(chunk),
+		func(i int) (interface{}, error) { return keyFn(chunk[i]) },
+		less,
+		func(i, j int) { chunk[i], chunk[j] = chunk[j], chunk[i] },
+	)
+	if err := ks.SortStable(); err != nil {
+		return nil, err
+	}
+	// The keys are memoized, so this does not call keyFn again, except for
+	// a lone Record that was never compared.
+	keys := make([]interface{}, len(chunk))
+	for i := range keys {
+		keys[i] = ks.Key(i)
+	}
+	return keys, ks.Errors()
+}
+
+// sliceSource is a RecordSource over Records held in memory.
+type sliceSource struct {
+	records []Record
+}
+
+// Next returns the next Record.
+func (s *sliceSource) Next() (Record, error) {
+	if len(s.records) == 0 {
+		return nil, io.EOF
+	}
+	record := s.records[0]
+	s.records = s.records[1:]
+	return record, nil
+}
+
+// Close releases the remaining Records.
+func (s *sliceSource) Close() error {
+	s.records = nil
+	return nil
+}
+
+// mergedSource k-way merges sorted runs that have been spilled to disk.
+type mergedSource struct {
+	keyFn func(Record) (interface{}, error)
+	// codec, if not nil, encodes the keys written alongside each Record.
+	codec KeyCodec
+	less  Comparator
+	// dir holds the run files, and is empty until the first spill.
+	dir   string
+	runs  []*runReader
+	heads runHeap
+}
+
+// spill writes a sorted chunk to a new run file, each Record followed by its
+// encoded key if there is a codec.
+func (m *mergedSource) spill(chunk []Record, keys []interface{}) error {
+	if m.dir == "" {
+		dir, err := os.MkdirTemp("", "keysort")
+		if err != nil {
+			return err
+		}
+		m.dir = dir
+	}
+
+	file, err := os.Create(filepath.Join(m.dir, strconv.Itoa(len(m.runs))))
+	if err != nil {
+		return err
+	}
+	m.runs = append(m.runs, &runReader{file: file, index: len(m.runs)})
+
+	w := bufio.NewWriter(file)
+	for i, record := range chunk {
+		if err := writeBlock(w, record); err != nil {
+			return err
+		}
+		if m.codec == nil {
+			continue
+		}
+		key, err := m.codec.EncodeKey(keys[i])
+		if err != nil {
+			return err
+		}
+		if err := writeBlock(w, key); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// writeBlock writes data to w, preceded by its length.
+func writeBlock(w *bufio.Writer, data []byte) error {
+	length := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(length, uint64(len(data)))
+	if _, err := w.Write(length[:n]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readBlock reads data written by writeBlock from r.
+func readBlock(r *bufio.Reader) ([]byte, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// start rewinds every run, and reads the first Record of each.
+func (m *mergedSource) start() error {
+	m.heads = runHeap{less: m.less}
+	for _, run := range m.runs {
+		if _, err := run.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		run.reader = bufio.NewReader(run.file)
+		if err := m.advance(run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// advance reads the next Record of run and its key, and pushes them onto the
+// heap if there is one.
+func (m *mergedSource) advance(run *runReader) error {
+	record, err := readBlock(run.reader)
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	key, err := m.readKey(run, record)
+	if err != nil {
+		return err
+	}
+	heap.Push(&m.heads, runHead{record, key, run})
+	return nil
+}
+
+// readKey reads the key written after record by spill, or computes it again if
+// there is no codec.
+func (m *mergedSource) readKey(run *runReader, record Record) (interface{}, error) {
+	if m.codec == nil {
+		return m.keyFn(record)
+	}
+	data, err := readBlock(run.reader)
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return nil, err
+	}
+	return m.codec.DecodeKey(data)
+}
+
+// Next returns the smallest remaining Record across all runs.
+func (m *mergedSource) Next() (Record, error) {
+	if len(m.heads.heads) == 0 {
+		m.Close()
+		return nil, io.EOF
+	}
+	head := heap.Pop(&m.heads).(runHead)
+	if err := m.advance(head.run); err != nil {
+		m.Close()
+		return nil, err
+	}
+	return head.record, nil
+}
+
+// Close removes the run files.
+func (m *mergedSource) Close() error {
+	for _, run := range m.runs {
+		run.file.Close()
+	}
+	m.runs = nil
+	m.heads.heads = nil
+	if m.dir == "" {
+		return nil
+	}
+	err := os.RemoveAll(m.dir)
+	m.dir = ""
+	return err
+}
+
+// runReader reads back a run file.
+type runReader struct {
+	file   *os.File
+	reader *bufio.Reader
+	// index orders runs, so that the merge is stable.
+	index int
+}
+
+// runHead is the next Record of a run, along with its key.
+type runHead struct {
+	record Record
+	key    interface{}
+	run    *runReader
+}
+
+// runHeap is a heap of runHeads, smallest key first.
+type runHeap struct {
+	heads []runHead
+	less  Comparator
+}
+
+func (h runHeap) Len() int      { return len(h.heads) }
+func (h runHeap) Swap(i, j int) { h.heads[i], h.heads[j] = h.heads[j], h.heads[i] }
+func (h runHeap) Less(i, j int) bool {
+	a, b := h.heads[i], h.heads[j]
+	if h.less(a.key, b.key) {
+		return true
+	}
+	if h.less(b.key, a.key) {
+		return false
+	}
+	return a.run.index < b.run.index
+}
+func (h *runHeap) Push(x interface{}) { h.heads = append(h.heads, x.(runHead)) }
+func (h *runHeap) Pop() interface{} {
+	last := h.heads[len(h.heads)-1]
+	h.heads = h.heads[:len(h.heads)-1]
+	return last
+}
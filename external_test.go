@@ -0,0 +1,95 @@
+package keysort
+
+import (
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+)
+
+// intCodec encodes int keys in decimal.
+type intCodec struct{}
+
+func (intCodec) EncodeKey(key interface{}) ([]byte, error) {
+	return []byte(strconv.Itoa(key.(int))), nil
+}
+func (intCodec) DecodeKey(data []byte) (interface{}, error) { return strconv.Atoi(string(data)) }
+
+func TestExternalKeysort(t *testing.T) {
+	// Without a codec, each key is computed again during the merge.
+	checkExternalKeysort(t, nil, 2)
+}
+
+func TestExternalKeysortCodec(t *testing.T) {
+	checkExternalKeysort(t, intCodec{}, 1)
+}
+
+// checkExternalKeysort sorts records spilled to disk using codec, and
+// expects keyFn to be called callsPerRecord times for each of them.
+func checkExternalKeysort(t *testing.T, codec KeyCodec, callsPerRecord int) {
+	const size = 100
+	input := &sliceSource{}
+	for _, n := range rand.Perm(size) {
+		input.records = append(input.records, Record(strconv.Itoa(n)))
+	}
+	keyCalls := 0
+	keyFn := func(r Record) (interface{}, error) {
+		keyCalls++
+		return strconv.Atoi(string(r))
+	}
+	less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+
+	output, err := ExternalKeysortCodec(input, keyFn, codec, less, 7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	merged, ok := output.(*mergedSource)
+	if !ok {
+		t.Fatalf("Expected runs to be spilled to disk")
+	}
+	dir := merged.dir
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("Expected the run directory to exist: %s", err)
+	}
+
+	for expected := 0; ; expected++ {
+		record, err := output.Next()
+		if err == io.EOF {
+			if expected != size {
+				t.Errorf("Expected %d records, got %d", size, expected)
+			}
+			break
+		} else if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if string(record) != strconv.Itoa(expected) {
+			t.Fatalf("Expected record %d, got %s", expected, record)
+		}
+	}
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected temporary files to be removed, got %v", err)
+	}
+	if keyCalls != callsPerRecord*size {
+		t.Errorf("Expected %d calls to keyFn, got %d", callsPerRecord*size, keyCalls)
+	}
+}
+
+func TestExternalKeysortInMemory(t *testing.T) {
+	input := &sliceSource{[]Record{Record("b"), Record("c"), Record("a")}}
+	keyFn := func(r Record) (interface{}, error) { return string(r), nil }
+	less := func(a, b interface{}) bool { return a.(string) < b.(string) }
+
+	output, err := ExternalKeysort(input, keyFn, less, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer output.(io.Closer).Close()
+
+	for _, expected := range []string{"a", "b", "c"} {
+		if record, _ := output.Next(); string(record) != expected {
+			t.Errorf("Expected %s, got %s", expected, record)
+		}
+	}
+}
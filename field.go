@@ -0,0 +1,74 @@
+package keysort
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// timeType is the reflect.Type of time.Time.
+var timeType = reflect.TypeOf(time.Time{})
+
+// KeysortField creates a keySortable over slice, which must be a slice of
+// structs, that sorts by the field named fieldName. The field must be exported,
+// and be an integer, float, string or time.Time; keys are compared with <, or
+// with time.Time.Before. An error is returned if slice or fieldName are not
+// suitable.
+func KeysortField(slice interface{}, fieldName string) (*keySortable, error) {
+	value := reflect.ValueOf(slice)
+	if value.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("keysort: KeysortField needs a slice, got %T", slice)
+	}
+	elemType := value.Type().Elem()
+	if elemType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("keysort: KeysortField needs a slice of structs, got %T", slice)
+	}
+	field, ok := elemType.FieldByName(fieldName)
+	if !ok {
+		return nil, fmt.Errorf("keysort: %s has no field %s", elemType, fieldName)
+	}
+	if field.PkgPath != "" {
+		return nil, fmt.Errorf("keysort: field %s of %s is not exported", fieldName, elemType)
+	}
+	less, err := defaultLess(field.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	return KeysortFunc(value.Len(),
+		func(i int) (interface{}, error) {
+			return value.Index(i).FieldByIndex(field.Index).Interface(), nil
+		},
+		less,
+		reflect.Swapper(slice),
+	), nil
+}
+
+// defaultLess returns a Comparator for values of type t.
+func defaultLess(t reflect.Type) (Comparator, error) {
+	if t == timeType {
+		return func(a, b interface{}) bool {
+			return a.(time.Time).Before(b.(time.Time))
+		}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(a, b interface{}) bool {
+			return reflect.ValueOf(a).Int() < reflect.ValueOf(b).Int()
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(a, b interface{}) bool {
+			return reflect.ValueOf(a).Uint() < reflect.ValueOf(b).Uint()
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(a, b interface{}) bool {
+			return reflect.ValueOf(a).Float() < reflect.ValueOf(b).Float()
+		}, nil
+	case reflect.String:
+		return func(a, b interface{}) bool {
+			return reflect.ValueOf(a).String() < reflect.ValueOf(b).String()
+		}, nil
+	}
+	return nil, fmt.Errorf("keysort: no default comparison for %s", t)
+}
@@ -0,0 +1,66 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+type Event struct {
+	Name     string
+	Priority int
+	At       time.Time
+	hidden   int
+}
+
+func genEvents() []Event {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []Event{
+		{"c", 2, base.Add(time.Hour), 0},
+		{"a", 3, base.Add(-time.Hour), 0},
+		{"b", 1, base, 0},
+	}
+}
+
+func TestKeysortField(t *testing.T) {
+	for _, test := range []struct {
+		field    string
+		expected string
+	}{
+		{"Name", "abc"},
+		{"Priority", "bca"},
+		{"At", "abc"},
+	} {
+		events := genEvents()
+		ks, err := KeysortField(events, test.field)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.field, err)
+		}
+		sort.Sort(ks)
+
+		actual := ""
+		for _, e := range events {
+			actual += e.Name
+		}
+		if actual != test.expected {
+			t.Errorf("%s: expected order %s, got %s", test.field, test.expected, actual)
+		}
+	}
+}
+
+func TestKeysortFieldErrors(t *testing.T) {
+	for name, test := range map[string]struct {
+		slice interface{}
+		field string
+	}{
+		"missing field":     {genEvents(), "Missing"},
+		"unexported field":  {genEvents(), "hidden"},
+		"not a slice":       {genEvents()[0], "Name"},
+		"not structs":       {[]int{1, 2}, "Name"},
+		"unsupported field": {[]struct{ F []int }{{nil}}, "F"},
+	} {
+		if _, err := KeysortField(test.slice, test.field); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package keysort
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+// NaNPolicy says how KeysortFloat orders keys that are NaN.
+type NaNPolicy int
+
+const (
+	// NaNFirst sorts NaN keys before every other key.
+	NaNFirst NaNPolicy = iota
+	// NaNLast sorts NaN keys after every other key.
+	NaNLast
+	// NaNError records ErrNaNKey against every element whose key is NaN.
+	NaNError
+)
+
+// ErrNaNKey is recorded against elements whose key is NaN, under the NaNError
+// policy.
+var ErrNaNKey = errors.New("keysort: key is NaN")
+
+// floatKeys wraps an Interface with float64 keys, applying a NaNPolicy.
+type floatKeys struct {
+	Interface
+	policy NaNPolicy
+}
+
+// KeysortFloat creates a keySortable over wrapped, whose keys must be float64,
+// that handles NaN keys according to policy. Without this, NaN keys compare
+// false against everything and end up scattered through the result.
+func KeysortFloat(wrapped Interface, policy NaNPolicy) *keySortable {
+	return Keysort(floatKeys{wrapped, policy})
+}
+
+// Key returns ErrNaNKey for NaN keys under the NaNError policy.
+func (f floatKeys) Key(i int) (interface{}, error) {
+	return f.checkNaN(f.Interface.Key(i))
+}
+
+// KeyCtx is like Key, but passes ctx on to the wrapped KeyCtx, if there is one.
+func (f floatKeys) KeyCtx(ctx context.Context, i int) (interface{}, error) {
+	return f.checkNaN(keyCtx(ctx, f.Interface, i))
+}
+
+func (f floatKeys) inner() Interface { return f.Interface }
+
+// checkNaN returns ErrNaNKey for a NaN value under the NaNError policy, and
+// value and err unchanged otherwise.
+func (f floatKeys) checkNaN(value interface{}, err error) (interface{}, error) {
+	if err == nil && f.policy == NaNError && isNaN(value) {
+		return value, ErrNaNKey
+	}
+	return value, err
+}
+
+// LessVal places NaN keys according to the policy, and otherwise delegates to
+// the wrapped LessVal.
+func (f floatKeys) LessVal(i, j interface{}) bool {
+	iNaN, jNaN := isNaN(i), isNaN(j)
+	switch {
+	case iNaN && jNaN:
+		return false
+	case iNaN:
+		return f.policy == NaNFirst
+	case jNaN:
+		return f.policy == NaNLast
+	}
+	return f.Interface.LessVal(i, j)
+}
+
+// isNaN reports whether value is a float64 NaN.
+func isNaN(value interface{}) bool {
+	f, ok := value.(float64)
+	return ok && math.IsNaN(f)
+}
+
+// epsilonKeys wraps an Interface with float64 keys, treating keys that are
+// within epsilon of each other as equal.
+type epsilonKeys struct {
+	Interface
+	epsilon float64
+}
+
+// KeysortFloatEpsilon creates a keySortable over wrapped, whose keys must be
+// float64, that treats keys within epsilon of each other as equal. Equal keys
+// keep their original order, as for KeysortDeterministic.
+//
+// Note that this equality is not transitive: a chain of keys each within
+// epsilon of the next need not all be within epsilon of each other, and the
+// order of such a chain depends on which pairs the sort compares.
+func KeysortFloatEpsilon(wrapped Interface, epsilon float64) *keySortable {
+	ks := Keysort(epsilonKeys{wrapped, epsilon})
+	ks.tiebreak = true
+	return ks
+}
+
+// KeyCtx passes ctx on to the wrapped KeyCtx, if there is one.
+func (e epsilonKeys) KeyCtx(ctx context.Context, i int) (interface{}, error) {
+	return keyCtx(ctx, e.Interface, i)
+}
+
+func (e epsilonKeys) inner() Interface { return e.Interface }
+
+// LessVal reports whether i is less than j by more than epsilon.
+func (e epsilonKeys) LessVal(i, j interface{}) bool {
+	return i.(float64) < j.(float64)-e.epsilon
+}
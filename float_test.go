@@ -0,0 +1,125 @@
+package keysort
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// genFloats returns size random floats, every third of which is NaN.
+func genFloats(size int) ByFloat {
+	floats := make(ByFloat, size)
+	for i := range floats {
+		if i%3 == 0 {
+			floats[i] = math.NaN()
+		} else {
+			floats[i] = rand.Float64()
+		}
+	}
+	rand.Shuffle(size, floats.Swap)
+	return floats
+}
+
+func TestKeysortFloatNaNFirst(t *testing.T) {
+	floats := genFloats(SPECIMEN_SIZE)
+
+	if err := KeysortFloat(floats, NaNFirst).Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	nans := (SPECIMEN_SIZE + 2) / 3
+	for i, f := range floats {
+		if (i < nans) != math.IsNaN(f) {
+			t.Errorf("NaNs not first: %v", floats)
+			break
+		}
+	}
+	if !sort.Float64sAreSorted(floats[nans:]) {
+		t.Errorf("Numbers not sorted: %v", floats)
+	}
+}
+
+func TestKeysortFloatNaNLast(t *testing.T) {
+	floats := genFloats(SPECIMEN_SIZE)
+
+	if err := KeysortFloat(floats, NaNLast).Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	numbers := SPECIMEN_SIZE - (SPECIMEN_SIZE+2)/3
+	for i, f := range floats {
+		if (i >= numbers) != math.IsNaN(f) {
+			t.Errorf("NaNs not last: %v", floats)
+			break
+		}
+	}
+	if !sort.Float64sAreSorted(floats[:numbers]) {
+		t.Errorf("Numbers not sorted: %v", floats)
+	}
+}
+
+func TestKeysortFloatNaNError(t *testing.T) {
+	floats := genFloats(SPECIMEN_SIZE)
+	nans := map[int]bool{}
+	for i, f := range floats {
+		if math.IsNaN(f) {
+			nans[i] = true
+		}
+	}
+
+	ks := KeysortFloat(floats, NaNError)
+	for i := range floats {
+		ks.Key(i)
+	}
+
+	err := ks.Errors()
+	if !errors.Is(err, ErrNaNKey) {
+		t.Fatalf("Expected ErrNaNKey, got %v", err)
+	}
+	for _, i := range err.(PrimingError).FailedIndices() {
+		if !nans[i] {
+			t.Errorf("Index %d is not NaN but was reported", i)
+		}
+	}
+	if len(err.(PrimingError).Errors) != len(nans) {
+		t.Errorf("Expected %d errors, got %d", len(nans), len(err.(PrimingError).Errors))
+	}
+}
+
+// ByFloat sorts float64s by their own value.
+type ByFloat []float64
+
+func (s ByFloat) LessVal(i, j interface{}) bool  { return i.(float64) < j.(float64) }
+func (s ByFloat) Key(i int) (interface{}, error) { return s[i], nil }
+func (s ByFloat) Swap(i, j int)                  { s[i], s[j] = s[j], s[i] }
+func (s ByFloat) Len() int                       { return len(s) }
+
+func TestKeysortFloatEpsilon(t *testing.T) {
+	// Pairs whose keys differ by less than epsilon should keep their order.
+	specimen := ByFloat{3.0, 1.05, 2.0, 1.0, 2.95, 1.02}
+
+	sort.Sort(KeysortFloatEpsilon(specimen, 0.1))
+
+	expected := ByFloat{1.05, 1.0, 1.02, 2.0, 3.0, 2.95}
+	for i := range expected {
+		if specimen[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, specimen)
+			break
+		}
+	}
+}
+
+func TestKeysortFloatEpsilonZero(t *testing.T) {
+	specimen := make(ByFloat, SPECIMEN_SIZE)
+	for i := range specimen {
+		specimen[i] = rand.Float64()
+	}
+
+	sort.Sort(KeysortFloatEpsilon(specimen, 0))
+
+	if !sort.Float64sAreSorted(specimen) {
+		t.Errorf("Expected a zero epsilon to sort exactly, got %v", specimen)
+	}
+}
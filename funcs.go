@@ -0,0 +1,33 @@
+package keysort
+
+// funcInterface implements Interface with closures.
+type funcInterface struct {
+	n    int
+	key  func(i int) (interface{}, error)
+	less func(a, b interface{}) bool
+	swap func(i, j int)
+}
+
+func (f funcInterface) LessVal(i, j interface{}) bool  { return f.less(i, j) }
+func (f funcInterface) Key(i int) (interface{}, error) { return f.key(i) }
+func (f funcInterface) Swap(i, j int)                  { f.swap(i, j) }
+func (f funcInterface) Len() int                       { return f.n }
+
+// KeysortFunc creates a keySortable from closures, in the manner of
+// sort.Slice, so that no type implementing Interface needs to be defined.
+// n is the length of the container, key and swap operate on the elements
+// currently at the given positions, and less compares two keys.
+func KeysortFunc(n int, key func(i int) (interface{}, error), less func(a, b interface{}) bool, swap func(i, j int)) *keySortable {
+	return Keysort(funcInterface{n, key, less, swap})
+}
+
+// KeysortMultiple is like KeysortFunc, for a container held in several
+// parallel slices of length n. Every function in swaps is called on each Swap,
+// in order, so that the slices stay aligned.
+func KeysortMultiple(n int, key func(i int) (interface{}, error), less Comparator, swaps ...func(i, j int)) *keySortable {
+	return KeysortFunc(n, key, less, func(i, j int) {
+		for _, swap := range swaps {
+			swap(i, j)
+		}
+	})
+}
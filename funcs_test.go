@@ -0,0 +1,72 @@
+package keysort
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func ExampleKeysortFunc() {
+	people := []struct {
+		Name    string
+		Friends []string
+	}{
+		{"alice", []string{"bob", "carol", "dave"}},
+		{"bob", []string{"alice"}},
+		{"carol", []string{"alice", "dave"}},
+	}
+
+	ks := KeysortFunc(len(people),
+		func(i int) (interface{}, error) {
+			return strings.Join(people[i].Friends, ","), nil
+		},
+		func(a, b interface{}) bool {
+			return len(a.(string)) < len(b.(string))
+		},
+		func(i, j int) {
+			people[i], people[j] = people[j], people[i]
+		})
+	sort.Sort(ks)
+
+	for _, p := range people {
+		fmt.Println(p.Name)
+	}
+	// Output:
+	// bob
+	// carol
+	// alice
+}
+
+func TestKeysortMultiple(t *testing.T) {
+	names := []string{"dave", "alice", "carol", "bob"}
+	ages := []int{40, 30, 30, 20}
+	scores := []int{1, 5, 2, 9}
+	expected := map[string][2]int{}
+	for i, name := range names {
+		expected[name] = [2]int{ages[i], scores[i]}
+	}
+
+	ks := KeysortMultiple(len(names),
+		func(i int) (interface{}, error) {
+			return ages[i]*100 + scores[i], nil
+		},
+		func(a, b interface{}) bool {
+			return a.(int) < b.(int)
+		},
+		func(i, j int) { names[i], names[j] = names[j], names[i] },
+		func(i, j int) { ages[i], ages[j] = ages[j], ages[i] },
+		func(i, j int) { scores[i], scores[j] = scores[j], scores[i] })
+	if err := ks.Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if got := strings.Join(names, ","); got != "bob,carol,alice,dave" {
+		t.Errorf("Expected bob,carol,alice,dave, got %s", got)
+	}
+	for i, name := range names {
+		if got := [2]int{ages[i], scores[i]}; got != expected[name] {
+			t.Errorf("%s is no longer aligned: expected %v, got %v", name, expected[name], got)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package keysort
+
+import (
+	"runtime/debug"
+	"sort"
+	"sync"
+)
+
+// GenericInterface is the type-parameterized counterpart of Interface. T is
+// the element type of the container, and K is the type of the key extracted
+// from each element. Because Key returns a K directly, LessKey never needs a
+// type assertion.
+type GenericInterface[T, K any] interface {
+	Key(i int) (K, error)
+	LessKey(a, b K) bool
+	Swap(i, j int)
+	Len() int
+}
+
+// genericKeySortable wraps a GenericInterface, and implements sort.Interface.
+// This is meant to be created by calling KeysortG.
+type genericKeySortable[T, K any] struct {
+	// wrapped is the container that must be sorted.
+	wrapped GenericInterface[T, K]
+	// swaps keeps track of the original index of the element currently at
+	// each position.
+	swaps []int
+	// memo maps the _original_ index of an element to the value of its Key()
+	// function.
+	memo map[int]K
+	// errors is a map of original indices to error objects encountered by this object.
+	errors map[int]error
+	// lock coordinates access to memo and errors.
+	sync.Mutex
+}
+
+// KeysortG creates a genericKeySortable, which implements sort.Interface, from
+// a GenericInterface, memoizing calls to wrapped.Key() in the same way as
+// Keysort. Once it has been sorted, Errors() reports any keys that failed.
+func KeysortG[T, K any](wrapped GenericInterface[T, K]) *genericKeySortable[T, K] {
+	wrappedLen := wrapped.Len()
+	swaps := make([]int, wrappedLen)
+	for i := range swaps {
+		swaps[i] = i
+	}
+
+	return &genericKeySortable[T, K]{
+		wrapped: wrapped,
+		swaps:   swaps,
+		memo:    make(map[int]K, wrappedLen),
+		errors:  make(map[int]error, errorsCapacity(wrappedLen)),
+	}
+}
+
+// Less is designed to implement sort.Interface. Delegates the call to
+// wrapped.LessKey() after retrieving (and memoizing if necessary) the keys for
+// i, j.
+func (ks *genericKeySortable[T, K]) Less(i, j int) bool {
+	IValue := ks.Key(i)
+	JValue := ks.Key(j)
+
+	// If there was an error, always return false from now on.
+	if ks.hasErrors() {
+		return false
+	}
+
+	return ks.wrapped.LessKey(IValue, JValue)
+}
+
+// Key calculates the value of calling wrapped.Key() on the element that is
+// currently at index i. A key that failed is not memoized, and the zero K is
+// returned for it.
+func (ks *genericKeySortable[T, K]) Key(i int) K {
+	originalIndex := ks.swaps[i]
+	var zero K
+
+	ks.Lock()
+	if value, ok := ks.memo[originalIndex]; ok {
+		ks.Unlock()
+		return value
+	}
+	if _, failed := ks.errors[originalIndex]; failed {
+		ks.Unlock()
+		return zero
+	}
+	// Release lock while calculating value of Key().
+	ks.Unlock()
+	value, err := ks.safeKey(i)
+
+	ks.Lock()
+	defer ks.Unlock()
+	if err != nil {
+		ks.errors[originalIndex] = err
+		return zero
+	}
+	ks.memo[originalIndex] = value
+	delete(ks.errors, originalIndex)
+	return value
+}
+
+// safeKey calls wrapped.Key() on the element currently at index i, converting a
+// panic into a KeyPanicError.
+func (ks *genericKeySortable[T, K]) safeKey(i int) (value K, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var zero K
+			value, err = zero, KeyPanicError{Index: ks.swaps[i], Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return ks.wrapped.Key(i)
+}
+
+// Len is designed to implement sort.Interface.
+// Delegates the call to to wrapped.Len()
+func (ks *genericKeySortable[T, K]) Len() int {
+	return ks.wrapped.Len()
+}
+
+// Swap is designed to implement sort.Interface.
+// Delegates the call to wrapped.Swap, while keeping track of the swaps.
+func (ks *genericKeySortable[T, K]) Swap(i, j int) {
+	ks.swaps[i], ks.swaps[j] = ks.swaps[j], ks.swaps[i]
+	ks.wrapped.Swap(i, j)
+}
+
+// hasErrors is a cheaper way to test whether Errors() is non-nil.
+func (ks *genericKeySortable[T, K]) hasErrors() bool {
+	ks.Lock()
+	defer ks.Unlock()
+	return len(ks.errors) != 0
+}
+
+// Errors returns a non-nil error if one or more of the Key functions returned
+// an error.
+func (ks *genericKeySortable[T, K]) Errors() error {
+	ks.Lock()
+	defer ks.Unlock()
+	if len(ks.errors) == 0 {
+		return nil
+	}
+	return PrimingError{Errors: copyErrors(ks.errors)}
+}
+
+// sliceG adapts a slice and functions to GenericInterface.
+type sliceG[T, K any] struct {
+	s    []T
+	key  func(T) (K, error)
+	less func(a, b K) bool
+}
+
+func (g sliceG[T, K]) Key(i int) (K, error) { return g.key(g.s[i]) }
+func (g sliceG[T, K]) LessKey(a, b K) bool  { return g.less(a, b) }
+func (g sliceG[T, K]) Swap(i, j int)        { g.s[i], g.s[j] = g.s[j], g.s[i] }
+func (g sliceG[T, K]) Len() int             { return len(g.s) }
+
+// SliceG sorts s by the keys that key computes from each element, compared
+// with less, in the manner of sort.Slice. key is called at most once per
+// element. If it fails for any element, the errors are returned, and the order
+// of s cannot be trusted.
+func SliceG[T, K any](s []T, key func(T) (K, error), less func(a, b K) bool) error {
+	ks := KeysortG[T, K](sliceG[T, K]{s, key, less})
+	sort.Sort(ks)
+	return ks.Errors()
+}
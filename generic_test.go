@@ -0,0 +1,142 @@
+package keysort
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestKeysortGByIntKey(t *testing.T) {
+	specimen := GenericByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	sort.Sort(KeysortG[ExampleToSort, int](specimen))
+
+	if !sort.IsSorted(ByIntKey{specimen.SpecimenSliceSorter}) {
+		t.Errorf("KeysortG failed for GenericByIntKey")
+	}
+}
+
+func TestKeysortGByStringKey(t *testing.T) {
+	specimen := GenericByStringKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	sort.Sort(KeysortG[ExampleToSort, string](specimen))
+
+	if !sort.IsSorted(ByStringKey{specimen.SpecimenSliceSorter}) {
+		t.Errorf("KeysortG failed for GenericByStringKey")
+	}
+}
+
+// GenericByIntKeyPanics panics computing the key of the element whose NotKey
+// is panicAt.
+type GenericByIntKeyPanics struct {
+	GenericByIntKey
+	panicAt int
+}
+
+func (s GenericByIntKeyPanics) Key(i int) (int, error) {
+	if s.At(i).NotKey == s.panicAt {
+		panic("bad element")
+	}
+	return s.GenericByIntKey.Key(i)
+}
+
+func TestKeysortGKeyPanics(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	for i := range specimen {
+		specimen[i].NotKey = i
+	}
+	ks := KeysortG[ExampleToSort, int](GenericByIntKeyPanics{GenericByIntKey{specimen}, 3})
+	sort.Sort(ks)
+
+	primingError, ok := ks.Errors().(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError, got %v", ks.Errors())
+	}
+	if _, ok := primingError.ErrorAt(3).(KeyPanicError); !ok {
+		t.Errorf("Expected a KeyPanicError for element 3, got %v", primingError.ErrorAt(3))
+	}
+	if _, ok := ks.memo[3]; ok {
+		t.Errorf("Expected the failed key not to be memoized")
+	}
+}
+
+type GenericByIntKey struct{ SpecimenSliceSorter }
+
+func (s GenericByIntKey) LessKey(a, b int) bool {
+	return a < b
+}
+
+func (s GenericByIntKey) Key(i int) (int, error) {
+	return s.At(i).IntKey, nil
+}
+
+type GenericByStringKey struct{ SpecimenSliceSorter }
+
+func (s GenericByStringKey) LessKey(a, b string) bool {
+	return a < b
+}
+
+func (s GenericByStringKey) Key(i int) (string, error) {
+	return s.At(i).StringKey, nil
+}
+
+func ExampleSliceG() {
+	type User struct {
+		Name    string
+		Friends []string
+	}
+	users := []User{
+		{"alice", []string{"bob", "carol", "dave"}},
+		{"bob", []string{"alice"}},
+		{"carol", []string{"alice", "dave"}},
+	}
+
+	SliceG(users,
+		func(u User) (int, error) { return len(u.Friends), nil },
+		func(a, b int) bool { return a < b })
+
+	for _, u := range users {
+		fmt.Println(u.Name)
+	}
+	// Output:
+	// bob
+	// carol
+	// alice
+}
+
+func TestSliceG(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	calls := 0
+
+	err := SliceG(specimen,
+		func(e ExampleToSort) (string, error) {
+			calls++
+			return e.StringKey, nil
+		},
+		func(a, b string) bool { return a < b })
+
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !sort.IsSorted(ByStringKey{specimen}) {
+		t.Errorf("SliceG failed to sort by StringKey")
+	}
+	if calls > SPECIMEN_SIZE {
+		t.Errorf("Expected at most %d key calls, got %d", SPECIMEN_SIZE, calls)
+	}
+}
+
+func TestSliceGErrors(t *testing.T) {
+	err := SliceG(GenSpecimen(SPECIMEN_SIZE),
+		func(e ExampleToSort) (int, error) {
+			if e.StringKey == "aaa" {
+				return 0, fmt.Errorf("Blah")
+			}
+			return e.IntKey, nil
+		},
+		func(a, b int) bool { return a < b })
+
+	if err == nil {
+		t.Errorf("Errors were expected.")
+	}
+}
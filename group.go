@@ -0,0 +1,32 @@
+package keysort
+
+import "sync"
+
+// PrimedKeysortGroup is like PrimedKeysort, but leaves scheduling to the
+// caller: run is called once for each index, with a task that memoizes its key
+// and returns the error that computing it produced, if any. run may call the
+// task synchronously, or arrange for it to be called from another goroutine,
+// as errgroup.Group.Go does, so that the caller's limits and cancellation
+// apply.
+//
+// run is only called from the goroutine that called PrimedKeysortGroup, but
+// tasks may be called concurrently. Every task must eventually be called
+// exactly once: PrimedKeysortGroup waits until all of them have returned.
+func PrimedKeysortGroup(wrapped Interface, run func(task func() error)) *keySortable {
+	ks := Keysort(wrapped)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(ks.Len())
+	for i := 0; i < ks.Len(); i++ {
+		i := i
+		run(func() error {
+			defer wg.Done()
+			ks.Key(i)
+			ks.Lock()
+			defer ks.Unlock()
+			return ks.errors[i]
+		})
+	}
+	wg.Wait()
+	return ks
+}
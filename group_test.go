@@ -0,0 +1,59 @@
+package keysort
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPrimedKeysortGroup(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	var submitted, failed int64
+
+	// Run every task in its own goroutine, like errgroup.Group.Go.
+	wg := sync.WaitGroup{}
+	ks := PrimedKeysortGroup(specimen, func(task func() error) {
+		atomic.AddInt64(&submitted, 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if task() != nil {
+				atomic.AddInt64(&failed, 1)
+			}
+		}()
+	})
+	wg.Wait()
+
+	if submitted != SPECIMEN_SIZE {
+		t.Errorf("Expected %d tasks, got %d", SPECIMEN_SIZE, submitted)
+	}
+	if failed != 0 {
+		t.Errorf("Expected no tasks to fail, got %d", failed)
+	}
+	if memoized := countMemoized(ks); memoized != SPECIMEN_SIZE {
+		t.Errorf("Expected %d memoized keys, got %d", SPECIMEN_SIZE, memoized)
+	}
+	sort.Sort(ks)
+	if !sort.IsSorted(specimen) {
+		t.Errorf("PrimedKeysortGroup failed for ByIntKey")
+	}
+}
+
+func TestPrimedKeysortGroupErrors(t *testing.T) {
+	specimen := ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)}
+	errs := []error{}
+
+	ks := PrimedKeysortGroup(specimen, func(task func() error) {
+		if err := task(); err != nil {
+			errs = append(errs, err)
+		}
+	})
+
+	if len(errs) != 1 {
+		t.Errorf("Expected one task to fail, got %v", errs)
+	}
+	if ks.Errors() == nil {
+		t.Errorf("Errors were expected.")
+	}
+}
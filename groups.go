@@ -0,0 +1,22 @@
+package keysort
+
+// SortGroups sorts wrapped, and returns the original indices of its elements
+// grouped into runs of equal keys, in sorted order. Two keys are equal if
+// LessVal orders neither before the other. If any key fails, the errors are
+// returned instead.
+func SortGroups(wrapped Interface) ([][]int, error) {
+	ks := Keysort(wrapped)
+	if err := ks.Sort(); err != nil {
+		return nil, err
+	}
+
+	groups := [][]int{}
+	for i := 0; i < ks.Len(); i++ {
+		if i == 0 || ks.wrapped.LessVal(ks.Key(i-1), ks.Key(i)) {
+			groups = append(groups, []int{})
+		}
+		last := len(groups) - 1
+		groups[last] = append(groups[last], ks.swaps[i])
+	}
+	return groups, nil
+}
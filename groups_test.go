@@ -0,0 +1,42 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestSortGroups(t *testing.T) {
+	specimen := ByIntKey{SpecimenSliceSorter{
+		{IntKey: 2}, {IntKey: 1}, {IntKey: 3}, {IntKey: 1}, {IntKey: 2}, {IntKey: 1},
+	}}
+
+	groups, err := SortGroups(specimen)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := [][]int{{1, 3, 5}, {0, 4}, {2}}
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected groups %v, got %v", expected, groups)
+	}
+	for g := range expected {
+		// Elements within a group may be in any order.
+		sort.Ints(groups[g])
+		if len(groups[g]) != len(expected[g]) {
+			t.Errorf("Expected groups %v, got %v", expected, groups)
+			continue
+		}
+		for i := range expected[g] {
+			if groups[g][i] != expected[g][i] {
+				t.Errorf("Expected groups %v, got %v", expected, groups)
+				break
+			}
+		}
+	}
+}
+
+func TestSortGroupsErrors(t *testing.T) {
+	if _, err := SortGroups(ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)}); err == nil {
+		t.Errorf("Errors were expected.")
+	}
+}
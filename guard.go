@@ -0,0 +1,62 @@
+package keysort
+
+import "sync/atomic"
+
+// guarded wraps an Interface with a record of which keySortable is sorting it.
+type guarded struct {
+	Interface
+	owner *atomic.Pointer[keySortable]
+}
+
+// Guarded returns an Interface that detects when two sorts of wrapped overlap,
+// which would otherwise corrupt it by swapping its elements from two goroutines
+// at once. The result is transparent: any optional interfaces wrapped
+// implements, such as KeyNormalizer, KeyContext, LessValErr or BatchKeyer, are
+// still used when it is sorted, and it is still detected beneath the package's
+// other wrappers, such as Counting or KeysortRange.
+//
+// Sorting the result with the Sort or Stable methods of a keySortable, or with
+// ParallelSort, panics if another sort of it is still running. A sort started
+// with sort.Sort or sort.Stable directly on a keySortable is checked on each
+// Swap instead, so it panics if another sort swaps elements at the same time.
+func Guarded(wrapped Interface) Interface {
+	return guarded{wrapped, &atomic.Pointer[keySortable]{}}
+}
+
+func (g guarded) unwrap() Interface { return g.Interface }
+
+// nested is implemented by the package's wrappers, to expose the Interface
+// they wrap, whether or not they are transparent.
+type nested interface {
+	inner() Interface
+}
+
+func (g guarded) inner() Interface { return g.Interface }
+
+// findGuard returns the owner of the Guarded container in wrapped's chain of
+// wrappers, or nil if there is none.
+func findGuard(wrapped Interface) *atomic.Pointer[keySortable] {
+	for {
+		if g, ok := wrapped.(guarded); ok {
+			return g.owner
+		}
+		n, ok := wrapped.(nested)
+		if !ok {
+			return nil
+		}
+		wrapped = n.inner()
+	}
+}
+
+// acquire marks a guarded container as being sorted by ks, panicking if it
+// already is. The returned function must be called once the sort is done. For
+// any other Interface, acquire does nothing.
+func (ks *keySortable) acquire() (release func()) {
+	if ks.guard == nil {
+		return func() {}
+	}
+	if !ks.guard.CompareAndSwap(nil, ks) {
+		panic("keysort: concurrent sorts of a Guarded container")
+	}
+	return func() { ks.guard.Store(nil) }
+}
@@ -0,0 +1,143 @@
+package keysort
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// ByIntKeyBlocking signals entered on its first call to Key, and then waits
+// for release to be closed.
+type ByIntKeyBlocking struct {
+	ByIntKey
+	once             *sync.Once
+	entered, release chan struct{}
+}
+
+func (s ByIntKeyBlocking) Key(i int) (interface{}, error) {
+	s.once.Do(func() {
+		close(s.entered)
+		<-s.release
+	})
+	return s.ByIntKey.Key(i)
+}
+
+func TestGuarded(t *testing.T) {
+	specimen := ByIntKeyBlocking{
+		ByIntKey{GenSpecimen(SPECIMEN_SIZE)},
+		&sync.Once{}, make(chan struct{}), make(chan struct{}),
+	}
+	g := Guarded(specimen)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Keysort(g).Sort()
+	}()
+	// The first sort is now stuck in its first call to Key.
+	<-specimen.entered
+
+	func() {
+		defer func() {
+			if r := recover(); r != "keysort: concurrent sorts of a Guarded container" {
+				t.Errorf("Expected a concurrent sort panic, got %v", r)
+			}
+		}()
+		Keysort(g).Sort()
+	}()
+	close(specimen.release)
+	<-done
+
+	// Once the first sort is done, the container may be sorted again.
+	if err := Keysort(g).Stable(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !sort.IsSorted(specimen.ByIntKey) {
+		t.Errorf("Guarded failed to sort")
+	}
+}
+
+func TestGuardedOptionalInterfaces(t *testing.T) {
+	mixed := MixedNumbers{3, 1.5, 2, 0.5, 4.25, 1}
+	if err := Keysort(Guarded(mixed)).Sort(); err != nil {
+		t.Errorf("Unexpected error from a Guarded KeyNormalizer: %s", err)
+	}
+	expected := MixedNumbers{0.5, 1, 1.5, 2, 3, 4.25}
+	for i := range expected {
+		if mixed[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, mixed)
+			break
+		}
+	}
+
+	unorderable := ByMixedKeys{GenSpecimen(SPECIMEN_SIZE)}
+	unorderable.SpecimenSliceSorter[3].NotKey = -1
+	if _, ok := Keysort(Guarded(unorderable)).Sort().(PrimingError); !ok {
+		t.Errorf("Expected a PrimingError from a Guarded LessValErr")
+	}
+
+	batched := newByIntKeyBatched()
+	PrimedKeysortBatched(Guarded(batched), -1, SPECIMEN_SIZE)
+	if len(*batched.batches) != 1 {
+		t.Errorf("Expected a single batch from a Guarded BatchKeyer, got %v", *batched.batches)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancellable := ByIntKeyCtx{ByIntKey{GenSpecimen(SPECIMEN_SIZE)}}
+	ks := KeysortContext(ctx, Guarded(cancellable))
+	ks.Key(0)
+	if primingError, ok := ks.Errors().(PrimingError); !ok || !errors.Is(primingError.ErrorAt(0), context.Canceled) {
+		t.Errorf("Expected a Guarded KeyContext to see the cancelled context")
+	}
+}
+
+func TestGuardedNested(t *testing.T) {
+	g := Guarded(ByIntKey{GenSpecimen(SPECIMEN_SIZE)})
+	counted, _ := Counting(g)
+	nested := map[string]*keySortable{
+		"Counting":     Keysort(counted),
+		"KeysortRange": KeysortRange(g, 1, SPECIMEN_SIZE-1),
+		"Reverse":      Keysort(Reverse(g)),
+	}
+	for name, ks := range nested {
+		func() {
+			release := Keysort(g).acquire()
+			defer release()
+			defer func() {
+				if r := recover(); r != "keysort: concurrent sorts of a Guarded container" {
+					t.Errorf("%s: expected a concurrent sort panic, got %v", name, r)
+				}
+			}()
+			ks.Sort()
+		}()
+	}
+}
+
+func TestGuardedPlainSort(t *testing.T) {
+	g := Guarded(ByIntKey{GenSpecimen(SPECIMEN_SIZE)})
+
+	// Another sort of g is running, so the first Swap of a plain sort.Sort
+	// panics.
+	release := Keysort(g).acquire()
+	func() {
+		defer func() {
+			if r := recover(); r != "keysort: concurrent sorts of a Guarded container" {
+				t.Errorf("Expected a concurrent sort panic, got %v", r)
+			}
+		}()
+		sort.Sort(Keysort(g))
+	}()
+	release()
+
+	ks := Keysort(g)
+	sort.Sort(ks)
+	if err := ks.Errors(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if ks.guard.Load() != nil {
+		t.Errorf("Expected the guard to be released after a plain sort")
+	}
+}
@@ -0,0 +1,28 @@
+package keysort
+
+// KeysortIndex sorts indices, which index into some external dataset, so that
+// the element at indices[0] of the dataset has the smallest key. key computes
+// the key of the element at a dataset index, and less compares two keys. Only
+// indices is reordered, so the dataset's records never move.
+//
+// Keys are memoized by dataset index, so key is called once per distinct index
+// even if indices holds duplicates. If key fails for any index, the errors are
+// returned, and the order of indices cannot be trusted.
+func KeysortIndex(indices []int, key func(datasetIndex int) (interface{}, error), less Comparator) error {
+	byDatasetIndex := make(map[int]keyResult, len(indices))
+	return KeysortFunc(len(indices),
+		func(i int) (interface{}, error) {
+			datasetIndex := indices[i]
+			if r, ok := byDatasetIndex[datasetIndex]; ok {
+				return r.value, r.err
+			}
+			value, err := key(datasetIndex)
+			byDatasetIndex[datasetIndex] = keyResult{value, err}
+			return value, err
+		},
+		less,
+		func(i, j int) {
+			indices[i], indices[j] = indices[j], indices[i]
+		},
+	).Sort()
+}
@@ -0,0 +1,63 @@
+package keysort
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestKeysortIndex(t *testing.T) {
+	dataset := GenSpecimen(SPECIMEN_SIZE)
+	// Sort every other record, and one of them twice.
+	indices := []int{}
+	for i := 0; i < len(dataset); i += 2 {
+		indices = append(indices, i)
+	}
+	indices = append(indices, 4)
+	original := append([]ExampleToSort{}, dataset...)
+
+	calls := map[int]int{}
+	err := KeysortIndex(indices,
+		func(datasetIndex int) (interface{}, error) {
+			calls[datasetIndex]++
+			return dataset[datasetIndex].IntKey, nil
+		},
+		func(a, b interface{}) bool { return a.(int) < b.(int) })
+
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if len(indices) != SPECIMEN_SIZE/2+1 {
+		t.Errorf("Expected %d indices, got %d", SPECIMEN_SIZE/2+1, len(indices))
+	}
+	for i := 1; i < len(indices); i++ {
+		if dataset[indices[i-1]].IntKey > dataset[indices[i]].IntKey {
+			t.Errorf("Indices not in order of key at %d: %v", i, indices)
+		}
+	}
+	for datasetIndex, n := range calls {
+		if n != 1 {
+			t.Errorf("Expected key to be called once for %d, got %d", datasetIndex, n)
+		}
+	}
+	for i := range original {
+		if dataset[i] != original[i] {
+			t.Errorf("Expected the dataset to be left alone")
+			break
+		}
+	}
+}
+
+func TestKeysortIndexErrors(t *testing.T) {
+	err := KeysortIndex([]int{3, 2, 1, 0},
+		func(datasetIndex int) (interface{}, error) {
+			if datasetIndex == 2 {
+				return nil, fmt.Errorf("Blah")
+			}
+			return datasetIndex, nil
+		},
+		func(a, b interface{}) bool { return a.(int) < b.(int) })
+
+	if err == nil {
+		t.Errorf("Errors were expected.")
+	}
+}
@@ -0,0 +1,56 @@
+package keysort
+
+import (
+	"container/heap"
+	"sync/atomic"
+)
+
+// Iterator returns a function that yields the original index of each element in
+// ascending order of key, one per call, then reports ok == false once every
+// element has been yielded. The wrapped container is never swapped.
+//
+// Elements are selected from a heap, so pulling the first few of n elements
+// costs O(n) comparisons rather than the O(n log n) of a full sort. Every key
+// is computed, or taken from the memo, when Iterator is called. If any key
+// fails, the iterator yields nothing, and Errors() reports why.
+func (ks *keySortable) Iterator() func() (origIndex int, ok bool) {
+	keys := make([]interface{}, ks.Len())
+	for i := range keys {
+		keys[i] = ks.Key(i)
+	}
+	if ks.hasErrors() {
+		return func() (int, bool) { return 0, false }
+	}
+
+	positions := make([]int, len(keys))
+	for i := range positions {
+		positions[i] = i
+	}
+	h := &minIndexHeap{indexSorter{positions, keys, func(a, b interface{}) bool {
+		atomic.AddInt64(&ks.comparisons, 1)
+		if ks.descending {
+			a, b = b, a
+		}
+		return ks.wrapped.LessVal(a, b)
+	}}}
+	heap.Init(h)
+
+	return func() (int, bool) {
+		if h.Len() == 0 {
+			return 0, false
+		}
+		return ks.swaps[heap.Pop(h).(int)], true
+	}
+}
+
+// minIndexHeap is a heap of indices whose root has the smallest key.
+type minIndexHeap struct {
+	indexSorter
+}
+
+func (h *minIndexHeap) Push(x interface{}) { h.indices = append(h.indices, x.(int)) }
+func (h *minIndexHeap) Pop() interface{} {
+	last := h.indices[len(h.indices)-1]
+	h.indices = h.indices[:len(h.indices)-1]
+	return last
+}
@@ -0,0 +1,71 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestIterator(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	original := append(SpecimenSliceSorter{}, specimen.SpecimenSliceSorter...)
+
+	ks := Keysort(specimen)
+	next := ks.Iterator()
+	previous := -1
+	count := 0
+	for {
+		i, ok := next()
+		if !ok {
+			break
+		}
+		if original[i].IntKey < previous {
+			t.Errorf("Iterator yielded %d after %d", original[i].IntKey, previous)
+		}
+		previous = original[i].IntKey
+		count++
+	}
+	if count != SPECIMEN_SIZE {
+		t.Errorf("Expected %d elements, got %d", SPECIMEN_SIZE, count)
+	}
+	for i := range original {
+		if specimen.SpecimenSliceSorter[i] != original[i] {
+			t.Errorf("Iterator swapped the container at %d", i)
+		}
+	}
+}
+
+func TestIteratorPartial(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	sorted := append(SpecimenSliceSorter{}, specimen.SpecimenSliceSorter...)
+	full := Keysort(ByIntKey{sorted})
+	sort.Sort(full)
+
+	ks := Keysort(specimen)
+	next := ks.Iterator()
+	for n := 0; n < 3; n++ {
+		i, ok := next()
+		if !ok {
+			t.Fatalf("Iterator stopped after %d elements", n)
+		}
+		if specimen.SpecimenSliceSorter[i].IntKey != sorted[n].IntKey {
+			t.Errorf("Element %d: expected key %d, got %d",
+				n, sorted[n].IntKey, specimen.SpecimenSliceSorter[i].IntKey)
+		}
+	}
+
+	if ks.Comparisons() >= full.Comparisons() {
+		t.Errorf("Expected fewer than %d comparisons for 3 elements, got %d",
+			full.Comparisons(), ks.Comparisons())
+	}
+}
+
+func TestIteratorErrors(t *testing.T) {
+	ks := Keysort(ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)})
+
+	if _, ok := ks.Iterator()(); ok {
+		t.Errorf("Expected the iterator to yield nothing")
+	}
+	if ks.Errors() == nil {
+		t.Errorf("Expected errors")
+	}
+}
@@ -0,0 +1,53 @@
+package keysort
+
+import "context"
+
+// KeyContext may be implemented by an Interface whose Key() can respect
+// cancellation and deadlines. When ks has a context, given to KeysortContext
+// or PrimedKeysortContext, KeyCtx is called instead of Key with a context
+// derived from it, which also carries the per-call deadline if a timeout is
+// set. Otherwise Key is called as usual. The package's own wrappers, such as
+// Reverse, Guarded, Counting, KeysortRange and KeysortFloat, pass KeyCtx on to
+// the Interface they wrap, so it is still used through them.
+type KeyContext interface {
+	KeyCtx(ctx context.Context, i int) (interface{}, error)
+}
+
+// KeysortContext is like Keysort, but passes a context derived from ctx to
+// each call of wrapped.KeyCtx(), if wrapped implements KeyContext. Once ctx is
+// done, a sort stops comparing keys, leaving the container partly sorted, and
+// ctx.Err() is returned by Errors().
+func KeysortContext(ctx context.Context, wrapped Interface) *keySortable {
+	ks := Keysort(wrapped)
+	ks.ctx = ctx
+	return ks
+}
+
+// key calls wrapped.KeyCtx() on the element currently at index i if wrapped
+// implements KeyContext and ks has a context, and wrapped.Key() otherwise.
+func (ks *keySortable) key(i int) (interface{}, error) {
+	keyer, ok := optional[KeyContext](ks.wrapped)
+	if !ok || ks.ctx == nil {
+		return ks.wrapped.Key(i)
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if ks.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ks.ctx, ks.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ks.ctx)
+	}
+	defer cancel()
+	return keyer.KeyCtx(ctx, i)
+}
+
+// keyCtx calls wrapped.KeyCtx() on the element at index i if wrapped implements
+// KeyContext, and wrapped.Key() otherwise. The package's wrappers use it to pass
+// KeyCtx on to the Interface they wrap.
+func keyCtx(ctx context.Context, wrapped Interface, i int) (interface{}, error) {
+	if keyer, ok := optional[KeyContext](wrapped); ok {
+		return keyer.KeyCtx(ctx, i)
+	}
+	return wrapped.Key(i)
+}
@@ -0,0 +1,143 @@
+package keysort
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+)
+
+// ByIntKeyCtx gives up on any key whose context is done.
+type ByIntKeyCtx struct{ ByIntKey }
+
+func (s ByIntKeyCtx) KeyCtx(ctx context.Context, i int) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.Key(i)
+}
+
+func TestKeysortContext(t *testing.T) {
+	specimen := ByIntKeyCtx{ByIntKey{GenSpecimen(SPECIMEN_SIZE)}}
+
+	ks := KeysortContext(context.Background(), specimen)
+	sort.Sort(ks)
+
+	if err := ks.Errors(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !sort.IsSorted(specimen) {
+		t.Errorf("KeysortContext failed for ByIntKeyCtx")
+	}
+}
+
+func TestKeysortContextCancelled(t *testing.T) {
+	specimen := ByIntKeyCtx{ByIntKey{GenSpecimen(SPECIMEN_SIZE)}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ks := KeysortContext(ctx, specimen)
+	sort.Sort(ks)
+
+	err := ks.Errors()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled in Errors(), got %v", err)
+	}
+}
+
+// ByIntKeyCtxOnly fails any key that is not computed through KeyCtx.
+type ByIntKeyCtxOnly struct{ ByIntKey }
+
+func (s ByIntKeyCtxOnly) Key(i int) (interface{}, error) {
+	return nil, errors.New("Key called instead of KeyCtx")
+}
+
+func (s ByIntKeyCtxOnly) KeyCtx(ctx context.Context, i int) (interface{}, error) {
+	return s.ByIntKey.Key(i)
+}
+
+func TestKeysortContextWrappers(t *testing.T) {
+	wrappers := map[string]func(Interface) *keySortable{
+		"Reverse": func(w Interface) *keySortable {
+			return KeysortContext(context.Background(), Reverse(w))
+		},
+		"Guarded": func(w Interface) *keySortable {
+			return KeysortContext(context.Background(), Guarded(w))
+		},
+		"Counting": func(w Interface) *keySortable {
+			counted, _ := Counting(w)
+			return KeysortContext(context.Background(), counted)
+		},
+		"KeysortRange": func(w Interface) *keySortable {
+			ks := KeysortRange(w, 2, SPECIMEN_SIZE-2)
+			ks.ctx = context.Background()
+			return ks
+		},
+	}
+	for name, wrap := range wrappers {
+		specimen := ByIntKeyCtxOnly{ByIntKey{GenSpecimen(SPECIMEN_SIZE)}}
+		if err := wrap(specimen).Sort(); err != nil {
+			t.Errorf("%s: unexpected error: %s", name, err)
+		}
+	}
+}
+
+func TestSortWithDescendingContext(t *testing.T) {
+	specimen := ByIntKeyCtxOnly{ByIntKey{GenSpecimen(SPECIMEN_SIZE)}}
+
+	err := SortWith(specimen, Options{Descending: true, Context: context.Background()})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !sort.IsSorted(sort.Reverse(specimen.ByIntKey)) {
+		t.Errorf("SortWith failed to sort descending through KeyCtx")
+	}
+}
+
+// ByIntKeyCancels cancels a context once Key has been called after times.
+type ByIntKeyCancels struct {
+	ByIntKey
+	after  int
+	calls  *int
+	cancel context.CancelFunc
+}
+
+func (s ByIntKeyCancels) Key(i int) (interface{}, error) {
+	*s.calls++
+	if *s.calls == s.after {
+		s.cancel()
+	}
+	return s.ByIntKey.Key(i)
+}
+
+func TestKeysortContextCancelledMidSort(t *testing.T) {
+	const size = 50 * SPECIMEN_SIZE
+	inner := GenSpecimen(size)
+	for i := range inner {
+		inner[i].NotKey = i
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	specimen := ByIntKeyCancels{ByIntKey{inner}, size / 2, new(int), cancel}
+
+	ks := KeysortContext(ctx, specimen)
+	if err := ks.Sort(); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if *specimen.calls != size/2 {
+		t.Errorf("Expected no keys computed after cancelling, got %d calls", *specimen.calls)
+	}
+
+	// Every element is still there exactly once, where swaps says it is.
+	seen := make([]bool, size)
+	for i, originalIndex := range ks.SortedIndices() {
+		if inner[i].NotKey != originalIndex {
+			t.Fatalf("Expected the element originally at %d at %d, found %d",
+				originalIndex, i, inner[i].NotKey)
+		}
+		if seen[originalIndex] {
+			t.Fatalf("Element %d appears twice", originalIndex)
+		}
+		seen[originalIndex] = true
+	}
+}
@@ -3,10 +3,15 @@
 package keysort
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // The keysort Interface must be implemented by any container type that you want
@@ -18,6 +23,35 @@ type Interface interface {
 	Len() int
 }
 
+// LessValErr may optionally be implemented by an Interface whose keys are not
+// always comparable. If it is, LessValE is used instead of LessVal, and any
+// error it returns is recorded as a CompareError.
+type LessValErr interface {
+	LessValE(a, b interface{}) (bool, error)
+}
+
+// unwrapper is implemented by the package's transparent wrappers, such as
+// Guarded, whose methods behave exactly as those of the Interface they wrap.
+type unwrapper interface {
+	unwrap() Interface
+}
+
+// optional returns wrapped as a T, looking through any transparent wrappers, so
+// that wrapping an Interface in one does not hide its optional interfaces.
+func optional[T any](wrapped Interface) (T, bool) {
+	for {
+		if t, ok := wrapped.(T); ok {
+			return t, true
+		}
+		u, ok := wrapped.(unwrapper)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		wrapped = u.unwrap()
+	}
+}
+
 // A KeySortable wraps an Interface, and implements sort.Interface.
 // This is meant to be created by calling Keysort(Interface)
 type keySortable struct {
@@ -26,106 +60,546 @@ type keySortable struct {
 	// swaps is a slice of ints to keep track of swaps that have been
 	// performed.
 	swaps []int
-	// memo is a temporary map to memoize the Key() function.
-	// memo maps the _original_ index of the element to the value of its Key() function.
-	memo map[int]interface{}
+	// memo memoizes the Key() function. It is indexed by the _original_ index
+	// of each element.
+	memo Memo
 	// errors is a map of original indices to error objects encountered by this object.
 	errors map[int]error
+	// keyCalls counts the calls made to wrapped.Key(). It is accessed
+	// atomically.
+	keyCalls int64
+	// comparisons counts the calls made to Less(). It is accessed atomically.
+	comparisons int64
+	// describe, if not nil, describes the element at an original index in
+	// PrimingErrors.
+	describe func(i int) string
+	// lenErr is set if wrapped is found to have changed length.
+	lenErr error
+	// genErr is set if the index generator given to memoize panicked.
+	genErr error
+	// ctxErr is set if a comparison found ctx cancelled.
+	ctxErr error
+	// pool, if not nil, runs memoization instead of fresh goroutines.
+	pool *workerPool
+	// timeout, if positive, is how long a single call to wrapped.Key() may
+	// take before it is abandoned.
+	timeout time.Duration
+	// retry, if not nil, says how to retry a failed call to wrapped.Key().
+	retry *RetryPolicy
+	// ctx, if not nil, is passed on to wrapped.KeyCtx() if wrapped implements
+	// KeyContext.
+	ctx context.Context
+	// batchSize is how many keys to compute in each call to KeyBatch, if
+	// wrapped implements BatchKeyer.
+	batchSize int
+	// isolateErrors, if set, makes only the elements whose keys failed sort
+	// specially, rather than every comparison once any key has failed.
+	isolateErrors bool
+	// tiebreak, if set, orders elements with equal keys by original index.
+	tiebreak bool
+	// descending, if set, flips the order in which keys are compared, so that
+	// wrapped is sorted in descending order of key.
+	descending bool
+	// checked, if set, verifies that LessVal never orders two keys both
+	// ways.
+	checked bool
+	// recoverLess, if set, converts a panic in wrapped.LessVal() into an
+	// error.
+	recoverLess bool
+	// sem, if not nil, bounds how many calls to wrapped.Key() may be in flight
+	// at once, across every keySortable that shares it.
+	sem chan struct{}
+	// inflight, if not nil, holds a channel for each original index whose key
+	// is being computed, which is closed once that key is memoized.
+	inflight map[int]chan struct{}
+	// swapLock, if not nil, is held for writing by Swap, so that background
+	// priming can hold it for reading while an element must stay in place.
+	swapLock *sync.RWMutex
+	// guard, if not nil, records which keySortable is sorting the Guarded
+	// container beneath wrapped.
+	guard *atomic.Pointer[keySortable]
+	// stopBackground, if not nil, stops background priming and waits for it
+	// to finish.
+	stopBackground func()
 	// lock coordinates access to memo and errors.
 	sync.Mutex
 }
 
 // Given an instance of a keysort.Interface, create a keySortable struct that
 // implements sort.Interface.
-func Keysort(wrapped Interface) (ks keySortable) {
-	wrappedLen := wrapped.Len()
-	swaps := make([]int, wrappedLen)
-	for i := 0; i < wrapped.Len(); i++ {
-		swaps[i] = i
+// The keySortable is returned by pointer, so that every copy shares the same
+// lock, memo and errors.
+// Keysort panics if wrapped is nil, as do all the constructors built on it.
+func Keysort(wrapped Interface) *keySortable {
+	if wrapped == nil {
+		panic("keysort: nil Interface")
 	}
-
-	ks.wrapped = wrapped
-	ks.memo = map[int]interface{}{}
-	ks.errors = map[int]error{}
-	ks.swaps = swaps
-	return
+	return KeysortWithMemo(wrapped, newSliceMemo(wrapped.Len()))
 }
 
 // Given an instance of a keysort.Interface, create a keySortable struct that
 // implements sort.Interface, and call memoize on it.
 // parallelism is how many goroutines to run at once while memoizing.
-func PrimedKeysort(wrapped Interface, parallelism int) (ks keySortable) {
-	ks = Keysort(wrapped)
+func PrimedKeysort(wrapped Interface, parallelism int) *keySortable {
+	ks := Keysort(wrapped)
 	ks.memoize(parallelism, ks.allIndexes)
-	return
+	return ks
+}
+
+// PrimedKeysortContext is like PrimedKeysort, but stops memoizing as soon as
+// ctx is cancelled. In that case ctx.Err() is returned along with the
+// keySortable, whose already-memoized keys are left intact so that a later sort
+// still benefits from them. If wrapped implements KeyContext, ctx is also
+// passed on to it while priming, as for KeysortContext, but the sort itself is
+// not cut short if ctx is cancelled.
+func PrimedKeysortContext(ctx context.Context, wrapped Interface, parallelism int) (*keySortable, error) {
+	ks := KeysortContext(ctx, wrapped)
+	ks.memoizeContext(ctx, parallelism, ks.allIndexes)
+	ks.ctx = nil
+	return ks, ctx.Err()
+}
+
+// PrimedKeysortDescribed is like PrimedKeysort, but any PrimingError returned
+// by Errors() describes each failing element with describe, which is given its
+// original index. describe is only called for failing indices.
+func PrimedKeysortDescribed(wrapped Interface, parallelism int, describe func(i int) string) *keySortable {
+	ks := Keysort(wrapped)
+	ks.describe = describe
+	ks.memoize(parallelism, ks.allIndexes)
+	return ks
+}
+
+// PrimedKeysortFailFast is like PrimedKeysort, but stops memoizing as soon as
+// any key fails, and returns the errors seen so far. Keys that were already
+// being computed when the first failure happened are allowed to finish.
+func PrimedKeysortFailFast(wrapped Interface, parallelism int) (*keySortable, error) {
+	ks := Keysort(wrapped)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var failed int32
+	ks.memoizeWith(ctx, parallelism, ks.allIndexes, func(i int) {
+		if atomic.LoadInt32(&failed) != 0 {
+			return
+		}
+		ks.Key(i)
+		if ks.hasErrors() {
+			atomic.StoreInt32(&failed, 1)
+			cancel()
+		}
+	})
+	return ks, ks.Errors()
+}
+
+// PrimedKeysortOrdered is like PrimedKeysort, but hands out indices to the
+// memoizing goroutines in the given order instead of 0..Len()-1. This helps
+// when the cost of Key() depends on locality. An error is returned, and
+// nothing is memoized, if order is not a permutation of 0..Len()-1.
+func PrimedKeysortOrdered(wrapped Interface, order []int, parallelism int) (*keySortable, error) {
+	ks := Keysort(wrapped)
+	seen := make([]bool, ks.Len())
+	if len(order) != len(seen) {
+		return ks, fmt.Errorf("%w: order has %d indices, expected %d", ErrLenMismatch, len(order), len(seen))
+	}
+	for _, i := range order {
+		if i < 0 || i >= len(seen) || seen[i] {
+			return ks, fmt.Errorf("keysort: order is not a permutation of 0..%d", len(seen)-1)
+		}
+		seen[i] = true
+	}
+
+	ks.MemoizeIndices(order, parallelism)
+	return ks, nil
+}
+
+// KeysortDeterministic is like Keysort, but elements whose keys are equal, in
+// that LessVal orders neither before the other, are ordered by their original
+// index. This makes sort.Sort give the same result as sort.Stable, at the cost
+// of an extra call to LessVal for every comparison that returns false.
+func KeysortDeterministic(wrapped Interface) *keySortable {
+	ks := Keysort(wrapped)
+	ks.tiebreak = true
+	return ks
+}
+
+// KeysortIsolateErrors is like Keysort, but an element whose key fails is
+// sorted after every element whose key did not, while those are still sorted
+// correctly among themselves. By default, once any key fails, every comparison
+// reports false, which can leave the whole container out of order. Errors()
+// still reports the failures.
+func KeysortIsolateErrors(wrapped Interface) *keySortable {
+	ks := Keysort(wrapped)
+	ks.isolateErrors = true
+	return ks
+}
+
+// By is the canonical name for Keysort. It creates a keySortable struct that
+// implements sort.Interface from an instance of keysort.Interface.
+func By(wrapped Interface) *keySortable {
+	return Keysort(wrapped)
+}
+
+// PrimedBy is the canonical name for PrimedKeysort. It creates a keySortable
+// struct that implements sort.Interface, and memoizes every key using
+// parallelism goroutines.
+func PrimedBy(wrapped Interface, parallelism int) *keySortable {
+	return PrimedKeysort(wrapped, parallelism)
+}
+
+// KeysortStable creates a keySortable struct that is intended to be passed to
+// sort.Stable. Memoization and swap-tracking behave exactly as for Keysort.
+func KeysortStable(wrapped Interface) *keySortable {
+	return Keysort(wrapped)
+}
+
+// Stable sorts the wrapped container with sort.Stable, so that elements with
+// equal keys keep their original relative order. It returns the result of
+// Errors() once the sort is done.
+func (ks *keySortable) Stable() error {
+	defer ks.acquire()()
+	sort.Stable(ks)
+	return ks.Errors()
+}
+
+// Sort sorts the wrapped container with sort.Sort, and returns the result of
+// Errors(). A non-nil result means the sort order cannot be trusted.
+func (ks *keySortable) Sort() error {
+	defer ks.acquire()()
+	sort.Sort(ks)
+	return ks.Errors()
+}
+
+// SortStable is the same as Stable, named to pair with Sort.
+func (ks *keySortable) SortStable() error {
+	return ks.Stable()
 }
 
 // Less is designed to implement sort.Interface. Delegates the call to
 // wrapped.ValLess() after retrieving (and memoizing if necessary) values for
 // the keys i, j.
-func (ks keySortable) Less(i, j int) bool {
+func (ks *keySortable) Less(i, j int) bool {
+	atomic.AddInt64(&ks.comparisons, 1)
+	if !ks.checkLen() || !ks.checkContext() {
+		return false
+	}
+
 	IValue := ks.Key(i)
 	JValue := ks.Key(j)
 
-	// If there was an error, always return false from now on.
-	if ks.Errors() != nil {
+	if ks.isolateErrors {
+		if iFailed, jFailed, ok := ks.failed(i, j); !ok {
+			return false
+		} else if iFailed || jFailed {
+			// Failed elements go after the rest, in no particular order.
+			return jFailed && !iFailed
+		}
+	} else if ks.hasErrors() {
+		// If there was an error, always return false from now on.
+		return false
+	}
+
+	if cmp, ok := ks.comparesThreeWays(); ok {
+		result, ok := ks.cmpVal(i, j, cmp, IValue, JValue)
+		if !ok {
+			return false
+		}
+		if result == 0 && ks.tiebreak {
+			// The keys are equal, so fall back to the original order.
+			return ks.swaps[i] < ks.swaps[j]
+		}
+		return result < 0
+	}
+
+	less, ok := ks.lessVal(i, j, IValue, JValue)
+	if !ok {
+		return false
+	}
+	if less && ks.checked && !ks.checkOrdering(i, j, IValue, JValue) {
 		return false
 	}
+	if !less && ks.tiebreak {
+		if greater, ok := ks.lessVal(j, i, JValue, IValue); ok && !greater {
+			// The keys are equal, so fall back to the original order.
+			return ks.swaps[i] < ks.swaps[j]
+		}
+	}
+	return less
+}
 
-	return ks.wrapped.LessVal(IValue, JValue)
+// comparesThreeWays returns wrapped as a CmpValer if Less can settle each
+// comparison with a single call to CmpVal: if it implements CmpValer, but not
+// LessValErr, and ks does not check the ordering, which needs both directions.
+func (ks *keySortable) comparesThreeWays() (CmpValer, bool) {
+	if ks.checked {
+		return nil, false
+	}
+	if _, ok := optional[LessValErr](ks.wrapped); ok {
+		return nil, false
+	}
+	return optional[CmpValer](ks.wrapped)
 }
 
 // Key calculates the value of calling wrapped.Key() on the element that is
 // currently at index i.
-func (ks keySortable) Key(i int) interface{} {
+func (ks *keySortable) Key(i int) interface{} {
 	// Look up the original index of what is currently at i
 	originalIndex := ks.swaps[i]
 	ks.Lock()
+	if value, ok := ks.lookupKey(originalIndex); ok {
+		ks.Unlock()
+		return value
+	}
+
+	var done chan struct{}
+	if ks.inflight != nil {
+		if waiting, ok := ks.inflight[originalIndex]; ok {
+			// Someone else is already computing this key, so wait for them.
+			ks.Unlock()
+			<-waiting
+			ks.Lock()
+			value, _ := ks.lookupKey(originalIndex)
+			ks.Unlock()
+			return value
+		}
+		done = make(chan struct{})
+		ks.inflight[originalIndex] = done
+	}
+
+	// The lock is not held, nor owed to a deferred Unlock, while calculating
+	// the value of Key(), so that a panic cannot leave it in a bad state.
+	ks.Unlock()
+	value, err := ks.callKey(i)
+	ks.Lock()
 	defer ks.Unlock()
-	var err error = nil
 
-	if _, ok := ks.memo[originalIndex]; !ok {
-		// Release lock while calculating value of Key().
-		ks.Unlock()
+	if done != nil {
+		delete(ks.inflight, originalIndex)
+		close(done)
+	}
 
-		var value interface{}
-		value, err = ks.wrapped.Key(originalIndex)
+	// Another goroutine may have computed this key while the lock was
+	// released. If so, its result wins, so that every caller sees the same
+	// value.
+	if existing, ok := ks.lookupKey(originalIndex); ok {
+		return existing
+	}
 
-		ks.Lock()
-		// Whatever happened, write the value down.
-		ks.memo[originalIndex] = value
+	ks.storeKey(originalIndex, value, err)
+	if err != nil {
+		return nil
+	}
+	return value
+}
 
-		if err != nil {
-			// If there was an error, note it.
-			ks.errors[originalIndex] = err
-		} else {
-			// If there wasn't an error, ensure it's cleared.
-			delete(ks.errors, originalIndex)
-		}
+// lookupKey returns the memoized key of the element at originalIndex, and
+// whether it needs no computing. A key that failed is not computed again until
+// its error is cleared, and nil is returned for it meanwhile. ks must be locked.
+func (ks *keySortable) lookupKey(originalIndex int) (interface{}, bool) {
+	if value, ok := ks.memo.Get(originalIndex); ok {
+		return value, true
+	}
+	if _, failed := ks.errors[originalIndex]; failed {
+		return nil, true
+	}
+	return nil, false
+}
+
+// storeKey records the result of computing the key of the element at
+// originalIndex. A key that failed is not memoized, so that whatever value came
+// with the error can never be compared or mistaken for a real key. ks must be
+// locked.
+func (ks *keySortable) storeKey(originalIndex int, value interface{}, err error) {
+	if err != nil {
+		ks.errors[originalIndex] = err
+		return
+	}
+	ks.memo.Put(originalIndex, value)
+	delete(ks.errors, originalIndex)
+}
+
+// callKey calls wrapped.Key() on the element that is currently at index i,
+// applying the timeout if one is set, recovering from any panic, retrying as
+// the RetryPolicy allows if there is one, and checking the result with
+// finishKey.
+func (ks *keySortable) callKey(i int) (interface{}, error) {
+	value, err := ks.attemptKey(i)
+	if err != nil && ks.retry != nil {
+		value, err = ks.retryKey(i, err)
+	}
+	return ks.finishKey(i, value, err)
+}
+
+// attemptKey makes a single call to wrapped.Key() on the element that is
+// currently at index i, for callKey.
+func (ks *keySortable) attemptKey(i int) (interface{}, error) {
+	atomic.AddInt64(&ks.keyCalls, 1)
+	if ks.sem != nil {
+		ks.sem <- struct{}{}
+	}
+	if ks.timeout > 0 {
+		// The slot is released once wrapped.Key() returns, even if that is
+		// after it has been abandoned.
+		return ks.callKeyTimeout(i, ks.releaseSem)
+	}
+	defer ks.releaseSem()
+	return ks.safeKey(i, ks.swaps[i])
+}
+
+// releaseSem frees the slot in ks.sem taken by attemptKey, if there is one.
+func (ks *keySortable) releaseSem() {
+	if ks.sem != nil {
+		<-ks.sem
 	}
-	return ks.memo[ks.swaps[i]]
+}
+
+// KeyCalls returns how many times wrapped.Key() has been called, counting each
+// index passed to KeyBatch as one call. Thanks to memoization, this should not
+// normally exceed Len().
+func (ks *keySortable) KeyCalls() int {
+	return int(atomic.LoadInt64(&ks.keyCalls))
+}
+
+// Comparisons returns how many times Less() has been called. For a full sort
+// this grows as O(n log n), while KeyCalls() stays at most n.
+func (ks *keySortable) Comparisons() int {
+	return int(atomic.LoadInt64(&ks.comparisons))
 }
 
 // Len is designed to implement sort.Interface.
-// Delegates the call to to wrapped.Len()
-func (ks keySortable) Len() int {
-	return ks.wrapped.Len()
+// Returns the length wrapped had when this keySortable was created, so that a
+// container that changes length can never make us index out of range.
+func (ks *keySortable) Len() int {
+	return len(ks.swaps)
+}
+
+// checkLen reports whether wrapped still has the length it had when this
+// keySortable was created. If not, a LenChangedError is recorded.
+func (ks *keySortable) checkLen() bool {
+	actual := ks.wrapped.Len()
+	if actual == len(ks.swaps) {
+		return true
+	}
+	ks.Lock()
+	defer ks.Unlock()
+	if ks.lenErr == nil {
+		ks.lenErr = LenChangedError{Expected: len(ks.swaps), Actual: actual}
+	}
+	return false
+}
+
+// checkContext reports whether ks has no context, or its context is not yet
+// done. Otherwise the context's error is recorded, and every comparison from
+// then on returns false without computing any keys, so that the rest of the
+// sort runs quickly. Since the container is only ever changed by Swap, it is
+// left holding the same elements, partly sorted.
+func (ks *keySortable) checkContext() bool {
+	if ks.ctx == nil {
+		return true
+	}
+	err := ks.ctx.Err()
+	if err == nil {
+		return true
+	}
+	ks.Lock()
+	defer ks.Unlock()
+	if ks.ctxErr == nil {
+		ks.ctxErr = err
+	}
+	return false
 }
 
 // Swap is designed to implement sort.Interface.
 // Delegates the call to wrapped.Swap, while keeping track of the swaps.
-func (ks keySortable) Swap(i, j int) {
+// If the length of wrapped has changed, it does nothing.
+func (ks *keySortable) Swap(i, j int) {
+	if !ks.checkLen() {
+		return
+	}
+	if ks.guard != nil && ks.guard.Load() != ks {
+		// Not sorting through Sort or Stable, so hold the guard for just this
+		// swap.
+		defer ks.acquire()()
+	}
+	if ks.swapLock != nil {
+		ks.swapLock.Lock()
+		defer ks.swapLock.Unlock()
+	}
 	ks.swaps[i], ks.swaps[j] = ks.swaps[j], ks.swaps[i]
 	ks.wrapped.Swap(i, j)
 }
 
+// IsSorted reports whether the wrapped container is sorted, comparing keys as
+// Less does. Keys that have not been memoized yet are computed (once). Unlike
+// sort.IsSorted(ks), it reports false if Errors() is not nil, since the order
+// of such a container cannot be trusted.
+func (ks *keySortable) IsSorted() bool {
+	if ks.hasErrors() {
+		return false
+	}
+	for i := ks.Len() - 1; i > 0; i-- {
+		if ks.Less(i, i-1) {
+			return false
+		}
+	}
+	return ks.Errors() == nil
+}
+
+// SortedIndices returns a copy of the permutation that has been applied to the
+// wrapped container, where result[newPos] is the original index of the element
+// now at newPos. It is only meaningful once a sort has completed.
+func (ks *keySortable) SortedIndices() []int {
+	result := make([]int, len(ks.swaps))
+	copy(result, ks.swaps)
+	return result
+}
+
 // memoize precomputes each wrapped.Key() in goroutines.
 // parallelism is how many goroutines to run at a time. If parallelism is less than one, an runtime.GOMAXPROCS goroutines are used.
-func (ks keySortable) memoize(parallelism int, genIndexes func(chan<- int)) {
+func (ks *keySortable) memoize(parallelism int, genIndexes func(chan<- int)) {
+	ks.memoizeContext(context.Background(), parallelism, genIndexes)
+}
+
+// memoizeContext is like memoize, but its goroutines stop taking indices from
+// genIndexes once ctx is cancelled.
+// If wrapped implements BatchKeyer, keys are computed in batches.
+func (ks *keySortable) memoizeContext(ctx context.Context, parallelism int, genIndexes func(chan<- int)) {
+	if batcher, ok := optional[BatchKeyer](ks.wrapped); ok {
+		ks.memoizeBatches(ctx, parallelism, genIndexes, batcher)
+		return
+	}
+	ks.memoizeWith(ctx, parallelism, genIndexes, func(i int) { ks.Key(i) })
+}
+
+// memoizeWith is like memoizeContext, but calls compute on each index instead
+// of Key, so that callers can do extra work around each key.
+// If ks has a worker pool, the work is submitted to it and parallelism is
+// ignored.
+func (ks *keySortable) memoizeWith(ctx context.Context, parallelism int, genIndexes func(chan<- int), compute func(i int)) {
 
 	// Channel on which we send indices to the key functions.
 	iChan := make(chan int)
 	wg := &sync.WaitGroup{}
+
+	if ks.pool != nil {
+		go ks.dedupIndexes(genIndexes, iChan)
+		for i := range iChan {
+			if ctx.Err() != nil {
+				// Keep draining, so that genIndexes doesn't leak.
+				continue
+			}
+			i := i
+			wg.Add(1)
+			ks.pool.submit(func() {
+				defer wg.Done()
+				if ctx.Err() == nil {
+					compute(i)
+				}
+			})
+		}
+		wg.Wait()
+		return
+	}
+
 	if parallelism < 1 {
 		parallelism = runtime.GOMAXPROCS(-1)
 	}
@@ -133,67 +607,208 @@ func (ks keySortable) memoize(parallelism int, genIndexes func(chan<- int)) {
 	wg.Add(parallelism)
 	for i := 0; i < parallelism; i++ {
 		go func() {
-			for i := range iChan {
-				ks.Key(i)
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case i, ok := <-iChan:
+					if !ok {
+						return
+					}
+					compute(i)
+				}
 			}
-			wg.Done()
 		}()
 	}
 
-	genIndexes(iChan)
+	go ks.dedupIndexes(genIndexes, iChan)
 	wg.Wait()
+
+	// If we were cancelled, let genIndexes run to completion so that it
+	// doesn't leak.
+	for range iChan {
+	}
+}
+
+// dedupIndexes runs genIndexes, and forwards each distinct index it generates
+// to iChan, so that no key is computed twice by one call to memoize. Indices
+// out of range are dropped, since there is no element to compute a key for.
+// iChan is closed once genIndexes closes its channel, or panics.
+func (ks *keySortable) dedupIndexes(genIndexes func(chan<- int), iChan chan<- int) {
+	generated := make(chan int)
+	go ks.safeGenIndexes(genIndexes, generated)
+
+	seen := make([]bool, ks.Len())
+	for i := range generated {
+		if i >= 0 && i < len(seen) && !seen[i] {
+			seen[i] = true
+			iChan <- i
+		}
+	}
+	close(iChan)
+}
+
+// MemoizeIndices precomputes the keys of the elements currently at indices,
+// using parallelism goroutines as for PrimedKeysort. It can be used to prime
+// only a subset of the container; duplicate indices are computed once, and
+// indices out of range are ignored.
+func (ks *keySortable) MemoizeIndices(indices []int, parallelism int) {
+	ks.memoize(parallelism, func(iChan chan<- int) {
+		for _, i := range indices {
+			iChan <- i
+		}
+		close(iChan)
+	})
 }
 
 // ClearErrors clears all the errors created on this keysort.
-func (ks keySortable) ClearErrors() {
+func (ks *keySortable) ClearErrors() {
 	ks.Lock()
 	defer ks.Unlock()
 	for k := range ks.errors {
 		delete(ks.errors, k)
 	}
-
 }
 
-// RetryFailed retries all the indexes that threw an error before
+// RetryFailed recomputes the keys of all the elements whose keys failed before.
 // parallelism is passed to memoize.
 // All past errors are cleared on a retry.
-func (ks keySortable) RetryFailed(parallelism int) {
+func (ks *keySortable) RetryFailed(parallelism int) {
+	failed := ks.erroredIndexes()
 	ks.ClearErrors()
-	ks.memoize(parallelism, ks.erroredIndexes)
+	ks.MemoizeIndices(failed, parallelism)
 }
 
 // allIndexes generates every possible index on the channel passed in as an
 // argument, and then closes the channel.
-func (ks keySortable) allIndexes(iChan chan<- int) {
+func (ks *keySortable) allIndexes(iChan chan<- int) {
 	for i := 0; i < ks.Len(); i++ {
 		iChan <- i
 	}
 	close(iChan)
 }
 
-// erroredIndexes generates only those indexes that have errored on the channel
-// passed in as an argument, and then closes the channel.
-func (ks keySortable) erroredIndexes(iChan chan<- int) {
-	erroredIndices := []int{}
+// erroredIndexes returns the current indexes of the elements whose keys have
+// errored.
+func (ks *keySortable) erroredIndexes() []int {
+	ks.Lock()
+	defer ks.Unlock()
+	erroredIndices := make([]int, 0, len(ks.errors))
+	for i, originalIndex := range ks.swaps {
+		if _, failed := ks.errors[originalIndex]; failed {
+			erroredIndices = append(erroredIndices, i)
+		}
+	}
+	return erroredIndices
+}
+
+// Errors returns a non-nil error if one or more of the Key functions returned
+// an error.
+// The returned PrimingError holds a copy of the errors, so it is safe to keep
+// and inspect while memoization continues.
+// If the length of wrapped has changed, a LenChangedError is returned instead,
+// and otherwise if priming stopped because its index generator panicked, an
+// IndexPanicError. Failing those, if a sort was cut short because the context
+// given to KeysortContext was cancelled, the context's error is returned.
+func (ks *keySortable) Errors() error {
 	ks.Lock()
-	for i := range ks.errors {
-		erroredIndices = append(erroredIndices, i)
+	if ks.lenErr != nil {
+		defer ks.Unlock()
+		return ks.lenErr
+	}
+	if ks.genErr != nil {
+		defer ks.Unlock()
+		return ks.genErr
 	}
+	if ks.ctxErr != nil {
+		defer ks.Unlock()
+		return ks.ctxErr
+	}
+	if len(ks.errors) == 0 {
+		ks.Unlock()
+		return nil
+	}
+	primingError := PrimingError{Errors: copyErrors(ks.errors)}
 	ks.Unlock()
+	return ks.describeErrors(primingError)
+}
 
-	for i := range erroredIndices {
-		iChan <- i
+// describeErrors fills in the Descriptions of primingError, if ks has a
+// describe function. describe is the caller's code, so the lock must not be
+// held while calling it.
+func (ks *keySortable) describeErrors(primingError PrimingError) PrimingError {
+	if ks.describe != nil {
+		primingError.Descriptions = make(map[int]string, len(primingError.Errors))
+		for i := range primingError.Errors {
+			primingError.Descriptions[i] = ks.describe(i)
+		}
 	}
-	close(iChan)
+	return primingError
 }
 
-// Errors returns a non-nil error if one or more of the Key functions returned
-// an error.
-func (ks keySortable) Errors() error {
+// failed reports whether the keys of the elements currently at i and j have
+// failed. ok is false if the length of wrapped has changed, in which case
+// nothing can be trusted.
+func (ks *keySortable) failed(i, j int) (iFailed, jFailed, ok bool) {
+	ks.Lock()
+	defer ks.Unlock()
+	_, iFailed = ks.errors[ks.swaps[i]]
+	_, jFailed = ks.errors[ks.swaps[j]]
+	return iFailed, jFailed, ks.lenErr == nil
+}
+
+// DrainErrors is like Errors, but also clears the errors it returns, all under
+// a single acquisition of the lock, so that no error can be recorded or cleared
+// between the two. This suits a loop that handles the errors and then retries.
+// A LenChangedError, an IndexPanicError or the error of a cancelled context is
+// returned in its place as by Errors, and is never cleared, since retrying
+// would not fix it.
+func (ks *keySortable) DrainErrors() error {
+	ks.Lock()
+	if ks.lenErr != nil {
+		defer ks.Unlock()
+		return ks.lenErr
+	}
+	if ks.genErr != nil {
+		defer ks.Unlock()
+		return ks.genErr
+	}
+	if ks.ctxErr != nil {
+		defer ks.Unlock()
+		return ks.ctxErr
+	}
 	if len(ks.errors) == 0 {
+		ks.Unlock()
 		return nil
 	}
-	return PrimingError{ks.errors}
+	primingError := PrimingError{Errors: ks.errors}
+	ks.errors = make(map[int]error, errorsCapacity(ks.Len()))
+	ks.Unlock()
+	return ks.describeErrors(primingError)
+}
+
+// hasErrors is a cheaper way to test whether Errors() is non-nil.
+func (ks *keySortable) hasErrors() bool {
+	ks.Lock()
+	defer ks.Unlock()
+	return ks.lenErr != nil || len(ks.errors) != 0
+}
+
+// recordError notes err against the original index originalIndex.
+func (ks *keySortable) recordError(originalIndex int, err error) {
+	ks.Lock()
+	defer ks.Unlock()
+	ks.errors[originalIndex] = err
+}
+
+// copyErrors returns a shallow copy of an errors map.
+func copyErrors(errors map[int]error) map[int]error {
+	result := make(map[int]error, len(errors))
+	for i, err := range errors {
+		result[i] = err
+	}
+	return result
 }
 
 // PrimingError is returned whenever a prime step fails. It may
@@ -201,16 +816,113 @@ func (ks keySortable) Errors() error {
 // if necessary.
 type PrimingError struct {
 	Errors map[int]error
+	// Descriptions optionally describes the element at each failing index,
+	// for a more helpful message.
+	Descriptions map[int]string
 }
 
 // Error returns a string representation of this error.
 func (e PrimingError) Error() string {
 	errorStrings := []string{}
-	for i, err := range e.Errors {
-		errorStrings = append(errorStrings, fmt.Sprintf("%d: %s", i, err.Error()))
+	for _, i := range e.FailedIndices() {
+		if description, ok := e.Descriptions[i]; ok {
+			errorStrings = append(errorStrings, fmt.Sprintf("\tindex %d (%s): %s\n", i, description, e.Errors[i].Error()))
+		} else {
+			errorStrings = append(errorStrings, fmt.Sprintf("\t%d: %s\n", i, e.Errors[i].Error()))
+		}
 	}
 
 	return fmt.Sprintf(
-		"Problem pre-computing Key functions.\n%s\n",
-		strings.Join(errorStrings, "\t%s\n"))
+		"Problem pre-computing Key functions.\n%s",
+		strings.Join(errorStrings, ""))
+}
+
+// CompareError is recorded when two keys could not be compared. I and J are the
+// original indices of the elements whose keys were being compared, and the
+// error is recorded against I.
+type CompareError struct {
+	I, J int
+	Err  error
+}
+
+// Error returns a string representation of this error.
+func (e CompareError) Error() string {
+	return fmt.Sprintf("comparing keys of %d and %d: %s", e.I, e.J, e.Err)
+}
+
+// Unwrap returns the error that caused the comparison to fail.
+func (e CompareError) Unwrap() error {
+	return e.Err
+}
+
+// ErrLenMismatch matches, with errors.Is, every error reporting that a length
+// is not the one expected, such as a LenChangedError.
+var ErrLenMismatch = errors.New("keysort: length mismatch")
+
+// LenChangedError is returned by Errors() when the wrapped container changed
+// length after the keySortable was created. Mutating a container while it is
+// being sorted is unsupported, but is reported rather than causing a panic.
+type LenChangedError struct {
+	Expected, Actual int
+}
+
+// Error returns a string representation of this error.
+func (e LenChangedError) Error() string {
+	return fmt.Sprintf("keysort: container length changed from %d to %d", e.Expected, e.Actual)
+}
+
+// Is reports whether target is ErrLenMismatch.
+func (e LenChangedError) Is(target error) bool {
+	return target == ErrLenMismatch
+}
+
+// NilKeyError is recorded against an element whose Key() returned a nil key
+// without an error, since LessVal cannot be expected to compare nil keys.
+type NilKeyError struct {
+	Index int
+}
+
+// Error returns a string representation of this error.
+func (e NilKeyError) Error() string {
+	return fmt.Sprintf("Key(%d) returned nil", e.Index)
+}
+
+// FailedIndices returns the original indices that failed, in ascending order.
+func (e PrimingError) FailedIndices() []int {
+	indices := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// Unwrap returns every error contained in this PrimingError, ordered by index,
+// so that errors.Is and errors.As match if any of the Key functions returned a
+// matching error.
+func (e PrimingError) Unwrap() []error {
+	result := make([]error, 0, len(e.Errors))
+	for _, i := range e.FailedIndices() {
+		result = append(result, e.Errors[i])
+	}
+	return result
+}
+
+// As reports whether any contained error, or any error in its chain, matches
+// target as for errors.As, trying them in order of index. If one does, target
+// is set to it. errors.As already finds these through Unwrap, but As can be
+// called directly on a PrimingError.
+func (e PrimingError) As(target interface{}) bool {
+	for _, err := range e.Unwrap() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorAt returns the error encountered for the original index i, or nil if
+// there was none.
+func (e PrimingError) ErrorAt(i int) error {
+	return e.Errors[i]
 }
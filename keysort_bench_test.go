@@ -0,0 +1,280 @@
+package keysort
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+const BENCHMARK_SIZE = 1000000
+
+// benchmarkSpecimen returns a ByIntKey over size elements in descending order.
+func benchmarkSpecimen(size int) ByIntKey {
+	specimen := make(SpecimenSliceSorter, size)
+	for i := range specimen {
+		specimen[i] = ExampleToSort{NotKey: i, IntKey: size - i}
+	}
+	return ByIntKey{specimen}
+}
+
+// BenchmarkMemoMap measures the memo access pattern of Key() when memoizing
+// into a map, as keySortable used to.
+func BenchmarkMemoMap(b *testing.B) {
+	specimen := benchmarkSpecimen(BENCHMARK_SIZE)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		memo := map[int]interface{}{}
+		for i := 0; i < BENCHMARK_SIZE; i++ {
+			if _, ok := memo[i]; !ok {
+				memo[i], _ = specimen.Key(i)
+			}
+		}
+		for i := 0; i < BENCHMARK_SIZE; i++ {
+			_ = memo[i]
+		}
+	}
+}
+
+// BenchmarkMemoMapSized is like BenchmarkMemoMap, but the map is created with
+// room for every key, as KeysortG's memo is, so it never has to grow.
+func BenchmarkMemoMapSized(b *testing.B) {
+	specimen := benchmarkSpecimen(BENCHMARK_SIZE)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		memo := make(map[int]interface{}, BENCHMARK_SIZE)
+		for i := 0; i < BENCHMARK_SIZE; i++ {
+			if _, ok := memo[i]; !ok {
+				memo[i], _ = specimen.Key(i)
+			}
+		}
+		for i := 0; i < BENCHMARK_SIZE; i++ {
+			_ = memo[i]
+		}
+	}
+}
+
+// BenchmarkMemoSlice measures the same access pattern as BenchmarkMemoMap
+// when memoizing into a preallocated slice of memoCells.
+func BenchmarkMemoSlice(b *testing.B) {
+	specimen := benchmarkSpecimen(BENCHMARK_SIZE)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		memo := make([]memoCell, BENCHMARK_SIZE)
+		for i := 0; i < BENCHMARK_SIZE; i++ {
+			if !memo[i].computed {
+				value, _ := specimen.Key(i)
+				memo[i] = memoCell{computed: true, value: value}
+			}
+		}
+		for i := 0; i < BENCHMARK_SIZE; i++ {
+			_ = memo[i].value
+		}
+	}
+}
+
+func BenchmarkKeysortMillion(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		specimen := benchmarkSpecimen(BENCHMARK_SIZE)
+		b.StartTimer()
+		sort.Sort(Keysort(specimen))
+	}
+}
+
+const PARALLEL_BENCHMARK_SIZE = 10000000
+
+func BenchmarkPrimedKeysortTenMillion(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		specimen := ByIntKey{GenSpecimen(PARALLEL_BENCHMARK_SIZE)}
+		b.StartTimer()
+		sort.Sort(PrimedKeysort(specimen, -1))
+	}
+}
+
+// BenchmarkParallelSortTenMillion needs more than one CPU to show a speedup
+// over BenchmarkPrimedKeysortTenMillion; with one, ParallelSort just sorts
+// sequentially.
+func BenchmarkParallelSortTenMillion(b *testing.B) {
+	if runtime.GOMAXPROCS(-1) == 1 {
+		b.Skip("ParallelSort sorts sequentially with GOMAXPROCS=1")
+	}
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		specimen := ByIntKey{GenSpecimen(PARALLEL_BENCHMARK_SIZE)}
+		b.StartTimer()
+		ParallelSort(specimen, -1)
+	}
+}
+
+// benchmarkCompositeCalls sorts a composite whose first sub-key has many ties,
+// reporting how many times that sub-key's comparator was called per sort.
+func benchmarkCompositeCalls(b *testing.B, first func(SpecimenSliceSorter, *int) Interface) {
+	calls := 0
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		specimen := benchmarkSpecimen(BENCHMARK_SIZE / 10).SpecimenSliceSorter
+		for i := range specimen {
+			specimen[i].IntKey %= 16
+		}
+		b.StartTimer()
+		sort.Sort(Keysort(Composite(first(specimen, &calls), ByStringKey{specimen})))
+	}
+	b.ReportMetric(float64(calls)/float64(b.N), "cmps/op")
+}
+
+func BenchmarkCompositeLessVal(b *testing.B) {
+	benchmarkCompositeCalls(b, func(s SpecimenSliceSorter, calls *int) Interface {
+		return withComparator{ByIntKey{s}, func(a, b interface{}) bool {
+			*calls++
+			return a.(int) < b.(int)
+		}}
+	})
+}
+
+func BenchmarkCompositeCmp(b *testing.B) {
+	benchmarkCompositeCalls(b, func(s SpecimenSliceSorter, calls *int) Interface {
+		return CmpInterface(ByIntKey{s}, func(a, b interface{}) int {
+			*calls++
+			return a.(int) - b.(int)
+		})
+	})
+}
+
+func BenchmarkKeysortGMillion(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		specimen := GenericByIntKey{benchmarkSpecimen(BENCHMARK_SIZE).SpecimenSliceSorter}
+		b.StartTimer()
+		sort.Sort(KeysortG[ExampleToSort, int](specimen))
+	}
+}
+
+func BenchmarkKeysortIntMillion(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		specimen := TypedByIntKey{benchmarkSpecimen(BENCHMARK_SIZE).SpecimenSliceSorter}
+		b.StartTimer()
+		sort.Sort(KeysortInt(specimen))
+	}
+}
+
+// benchmarkReuse primes and sorts BENCHMARK_SIZE / 10 elements on each
+// iteration, using sorter to get a keySortable that is ready to prime.
+func benchmarkReuse(b *testing.B, sorter func(ByIntKey) *keySortable) {
+	b.ReportAllocs()
+	specimen := benchmarkSpecimen(BENCHMARK_SIZE / 10)
+	fresh := benchmarkSpecimen(BENCHMARK_SIZE / 10)
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		copy(specimen.SpecimenSliceSorter, fresh.SpecimenSliceSorter)
+		b.StartTimer()
+		ks := sorter(specimen)
+		ks.memoize(-1, ks.allIndexes)
+		sort.Sort(ks)
+	}
+}
+
+func BenchmarkPrimedKeysortReconstruct(b *testing.B) {
+	benchmarkReuse(b, func(specimen ByIntKey) *keySortable {
+		return Keysort(specimen)
+	})
+}
+
+func BenchmarkPrimedKeysortReset(b *testing.B) {
+	var ks *keySortable
+	benchmarkReuse(b, func(specimen ByIntKey) *keySortable {
+		if ks == nil {
+			ks = Keysort(specimen)
+		} else {
+			ks.Reset()
+		}
+		return ks
+	})
+}
+
+const SMALL_BENCHMARK_SIZE = 10
+
+func BenchmarkKeysortSmall(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		specimen := benchmarkSpecimen(SMALL_BENCHMARK_SIZE)
+		b.StartTimer()
+		sort.Sort(Keysort(specimen))
+	}
+}
+
+func BenchmarkUnmemoizedSmall(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		specimen := benchmarkSpecimen(SMALL_BENCHMARK_SIZE)
+		b.StartTimer()
+		sort.Sort(Unmemoized(specimen))
+	}
+}
+
+// benchScale multiplies the sizes used by the expensive-key benchmarks, which
+// are kept small by default so that they run quickly.
+var benchScale = flag.Int("keysort.benchscale", 1, "scale the size of the expensive-key benchmarks")
+
+// costlyKeys is a ByIntKey whose Key burns cost iterations of busywork before
+// returning, to stand in for an expensive key function.
+type costlyKeys struct {
+	ByIntKey
+	cost int
+}
+
+func (c costlyKeys) Key(i int) (interface{}, error) {
+	h := uint32(i)
+	for n := 0; n < c.cost; n++ {
+		h = h*16777619 ^ uint32(n)
+	}
+	if h == 0 {
+		// Never true in practice, but keeps the loop from being optimized away.
+		return nil, fmt.Errorf("unlucky hash")
+	}
+	return c.ByIntKey.Key(i)
+}
+
+// benchmarkCostly runs sorter over a shuffled costlyKeys for each combination
+// of Len() and key cost.
+func benchmarkCostly(b *testing.B, sorter func(costlyKeys)) {
+	for _, size := range []int{100, 1000} {
+		for _, cost := range []int{10, 1000} {
+			size := size * *benchScale
+			b.Run(fmt.Sprintf("len=%d/cost=%d", size, cost), func(b *testing.B) {
+				for n := 0; n < b.N; n++ {
+					b.StopTimer()
+					specimen := costlyKeys{ByIntKey{GenSpecimen(size)}, cost}
+					b.StartTimer()
+					sorter(specimen)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkNaiveSort(b *testing.B) {
+	benchmarkCostly(b, func(specimen costlyKeys) {
+		sort.Sort(Unmemoized(specimen))
+	})
+}
+
+func BenchmarkKeysort(b *testing.B) {
+	benchmarkCostly(b, func(specimen costlyKeys) {
+		sort.Sort(Keysort(specimen))
+	})
+}
+
+func BenchmarkPrimedKeysort(b *testing.B) {
+	benchmarkCostly(b, func(specimen costlyKeys) {
+		sort.Sort(PrimedKeysort(specimen, -1))
+	})
+}
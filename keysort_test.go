@@ -1,10 +1,18 @@
 package keysort
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
+	"os"
+	"os/exec"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 const SPECIMEN_SIZE = 20
@@ -48,15 +56,86 @@ func TestKeysortByIntKey(t *testing.T) {
 }
 
 func TestKeysortByIntKeyCounted(t *testing.T) {
-	specimen := ByIntKeyCounted{GenSpecimen(SPECIMEN_SIZE), 0}
-	sort.Sort(Keysort(specimen))
+	specimen := ByIntKeyCounted{GenSpecimen(SPECIMEN_SIZE), new(int64)}
+	ks := Keysort(specimen)
+	sort.Sort(ks)
 
 	if !sort.IsSorted(ByIntKey{specimen.SpecimenSliceSorter}) {
 		t.Errorf("Keysort failed for ByIntKey.")
 	}
 
-	if specimen.count > SPECIMEN_SIZE {
-		t.Errorf("Key() called too often.")
+	if *specimen.count == 0 || *specimen.count > SPECIMEN_SIZE {
+		t.Errorf("Key() called %d times.", *specimen.count)
+	}
+	if ks.KeyCalls() != int(*specimen.count) {
+		t.Errorf("KeyCalls() is %d, but Key() was called %d times.", ks.KeyCalls(), *specimen.count)
+	}
+}
+
+func TestComparisons(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	ks := Keysort(specimen)
+	sort.Sort(ks)
+
+	if ks.Comparisons() < SPECIMEN_SIZE-1 || ks.Comparisons() > SPECIMEN_SIZE*(SPECIMEN_SIZE-1)/2 {
+		t.Errorf("Comparisons() is %d, outside the expected bounds", ks.Comparisons())
+	}
+	if ks.KeyCalls() > SPECIMEN_SIZE {
+		t.Errorf("KeyCalls() is %d, expected at most %d", ks.KeyCalls(), SPECIMEN_SIZE)
+	}
+}
+
+func TestKeyCallsDistinctIndices(t *testing.T) {
+	specimen := ByIntKeyCounted{GenSpecimen(SPECIMEN_SIZE), new(int64)}
+	ks := Keysort(specimen)
+
+	needed := []int{0, 4, 4, 9, 0, 13}
+	for _, i := range needed {
+		ks.Key(i)
+	}
+	if ks.KeyCalls() != 4 {
+		t.Errorf("Expected 4 key calls, got %d", ks.KeyCalls())
+	}
+
+	ks.MemoizeIndices([]int{0, 1, 2, 3, 4}, -1)
+	if ks.KeyCalls() != 7 {
+		t.Errorf("Expected 7 key calls, got %d", ks.KeyCalls())
+	}
+}
+
+func TestMemoizeIndicesOutOfRange(t *testing.T) {
+	ks := Keysort(ByIntKey{GenSpecimen(SPECIMEN_SIZE)})
+
+	ks.MemoizeIndices([]int{-1, 0, SPECIMEN_SIZE, 1}, -1)
+	if ks.PrimedCount() != 2 {
+		t.Errorf("Expected the 2 indices in range to be memoized, got %d", ks.PrimedCount())
+	}
+	if err := ks.Errors(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+func TestKeyAfterSwap(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	ks := Keysort(specimen)
+
+	// Nothing has been memoized, so the keys must be computed from where the
+	// elements are now, not from their original indices.
+	ks.Swap(0, 1)
+	if key := ks.Key(0); key != 0 {
+		t.Errorf("Expected the key of the element moved to 0 to be 0, got %v", key)
+	}
+	if key := ks.Key(1); key != 1 {
+		t.Errorf("Expected the key of the element moved to 1 to be 1, got %v", key)
+	}
+
+	// The memoized keys follow their elements through later swaps.
+	ks.Swap(0, 1)
+	if ks.Key(0) != 1 || ks.Key(1) != 0 {
+		t.Errorf("Expected memoized keys to follow their elements")
+	}
+	if ks.KeyCalls() != 2 {
+		t.Errorf("Expected 2 key calls, got %d", ks.KeyCalls())
 	}
 }
 
@@ -70,6 +149,113 @@ func TestPrimedKeysortByIntKey(t *testing.T) {
 	}
 }
 
+func TestByIntKey(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	sort.Sort(By(specimen))
+
+	if !sort.IsSorted(specimen) {
+		t.Errorf("By failed for ByIntKey")
+	}
+}
+
+func TestPrimedByIntKey(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	sort.Sort(PrimedBy(specimen, -1))
+
+	if !sort.IsSorted(specimen) {
+		t.Errorf("PrimedBy failed for ByIntKey")
+	}
+}
+
+func TestKeysortStablePreservesOrder(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	for i := range specimen.SpecimenSliceSorter {
+		// Use NotKey to remember the input order, and force duplicate keys.
+		specimen.SpecimenSliceSorter[i].NotKey = i
+		specimen.SpecimenSliceSorter[i].IntKey = rand.Intn(3)
+	}
+
+	sort.Stable(KeysortStable(specimen))
+	assertStable(t, specimen)
+
+	specimen = ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	for i := range specimen.SpecimenSliceSorter {
+		specimen.SpecimenSliceSorter[i].NotKey = i
+		specimen.SpecimenSliceSorter[i].IntKey = rand.Intn(3)
+	}
+
+	if err := Keysort(specimen).Stable(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	assertStable(t, specimen)
+}
+
+// assertStable checks that specimen is sorted by IntKey, and that elements
+// with equal IntKeys are in ascending order of NotKey.
+func assertStable(t *testing.T, specimen ByIntKey) {
+	t.Helper()
+	if !sort.IsSorted(specimen) {
+		t.Errorf("Stable keysort failed for ByIntKey")
+	}
+	for i := 1; i < specimen.Len(); i++ {
+		prev, cur := specimen.At(i-1), specimen.At(i)
+		if prev.IntKey == cur.IntKey && prev.NotKey > cur.NotKey {
+			t.Errorf("Relative order of equal keys not preserved at %d", i)
+		}
+	}
+}
+
+func TestIsSortedUsesMemo(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	ks := PrimedKeysort(specimen, -1)
+
+	if ks.IsSorted() {
+		t.Errorf("Expected an unsorted container to be reported unsorted")
+	}
+	sort.Sort(ks)
+	calls := ks.KeyCalls()
+
+	if !ks.IsSorted() {
+		t.Errorf("Expected a sorted container to be reported sorted")
+	}
+	if ks.KeyCalls() != calls {
+		t.Errorf("IsSorted made %d new Key calls", ks.KeyCalls()-calls)
+	}
+}
+
+func TestIsSortedFailedKey(t *testing.T) {
+	// A failed key reads back as nil, which LessVal must never see.
+	ks := Keysort(newByIntKeyFailsOnce(3))
+	if ks.IsSorted() {
+		t.Errorf("Expected a container with a failed key to be reported unsorted")
+	}
+
+	ks = PrimedKeysort(newByIntKeyFailsOnce(3), -1)
+	if ks.IsSorted() {
+		t.Errorf("Expected a primed container with a failed key to be reported unsorted")
+	}
+}
+
+func TestSortedIndices(t *testing.T) {
+	original := GenSpecimen(SPECIMEN_SIZE)
+	specimen := ByIntKey{append(SpecimenSliceSorter{}, original...)}
+
+	ks := Keysort(specimen)
+	sort.Sort(ks)
+
+	indices := ks.SortedIndices()
+	if len(indices) != SPECIMEN_SIZE {
+		t.Fatalf("Expected %d indices, got %d", SPECIMEN_SIZE, len(indices))
+	}
+	for newPos, originalIndex := range indices {
+		if original[originalIndex] != specimen.At(newPos) {
+			t.Errorf("Permutation disagrees with sorted container at %d", newPos)
+		}
+	}
+}
+
 func TestKeysortByStringKey(t *testing.T) {
 	specimen := ByStringKey{GenSpecimen(SPECIMEN_SIZE)}
 
@@ -102,6 +288,163 @@ func TestPrimedKeysortByStringKeyErrors(t *testing.T) {
 	}
 }
 
+func TestSortReturnsErrors(t *testing.T) {
+	for name, sortFunc := range map[string]func(*keySortable) error{
+		"Sort":       (*keySortable).Sort,
+		"SortStable": (*keySortable).SortStable,
+	} {
+		specimen := ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)}
+
+		err := sortFunc(Keysort(specimen))
+
+		primingError, ok := err.(PrimingError)
+		if !ok {
+			t.Errorf("%s: expected a PrimingError, got %v", name, err)
+		} else if _, ok := primingError.Errors[1]; !ok || len(primingError.Errors) != 1 {
+			t.Errorf("%s: expected exactly one error, for index 1, got %v", name, primingError.Errors)
+		}
+	}
+
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	if err := Keysort(specimen).Sort(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !sort.IsSorted(specimen) {
+		t.Errorf("Sort failed for ByIntKey")
+	}
+}
+
+// TemporaryError is a custom error type, that Key functions may wrap.
+type TemporaryError struct {
+	Attempt int
+}
+
+func (e *TemporaryError) Error() string {
+	return fmt.Sprintf("temporary failure on attempt %d", e.Attempt)
+}
+
+func TestPrimingErrorAs(t *testing.T) {
+	cause := &TemporaryError{Attempt: 3}
+	var err error = PrimingError{Errors: map[int]error{
+		2: io.EOF,
+		5: fmt.Errorf("fetching key: %w", cause),
+	}}
+
+	var temporary *TemporaryError
+	if !errors.As(err, &temporary) || temporary != cause {
+		t.Errorf("Expected errors.As to extract the TemporaryError, got %v", temporary)
+	}
+	temporary = nil
+	if !err.(PrimingError).As(&temporary) || temporary != cause {
+		t.Errorf("Expected As to extract the TemporaryError, got %v", temporary)
+	}
+	var pathError *os.PathError
+	if err.(PrimingError).As(&pathError) {
+		t.Errorf("Did not expect As to match an os.PathError")
+	}
+}
+
+func TestPrimingErrorIs(t *testing.T) {
+	err := PrimingError{Errors: map[int]error{
+		2: io.EOF,
+		5: fmt.Errorf("wrapped: %w", io.ErrUnexpectedEOF),
+	}}
+
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("Expected errors.Is to match io.EOF")
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected errors.Is to match a wrapped io.ErrUnexpectedEOF")
+	}
+	if errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Did not expect errors.Is to match io.ErrClosedPipe")
+	}
+	if err.ErrorAt(2) != io.EOF || err.ErrorAt(3) != nil {
+		t.Errorf("ErrorAt returned the wrong errors")
+	}
+}
+
+func TestPrimingErrorString(t *testing.T) {
+	err := PrimingError{Errors: map[int]error{10: fmt.Errorf("bang"), 3: fmt.Errorf("boom")}}
+
+	expected := "Problem pre-computing Key functions.\n\t3: boom\n\t10: bang\n"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestPrimingErrorFailedIndices(t *testing.T) {
+	specimen := ByIntKeyHalfErrors{GenSpecimen(SPECIMEN_SIZE)}
+
+	err := PrimedKeysort(specimen, -1).Errors().(PrimingError)
+
+	indices := err.FailedIndices()
+	if !sort.IntsAreSorted(indices) {
+		t.Errorf("FailedIndices not sorted: %v", indices)
+	}
+	if len(indices) != SPECIMEN_SIZE/2 {
+		t.Errorf("Expected %d failed indices, got %d", SPECIMEN_SIZE/2, len(indices))
+	}
+	for n, i := range indices {
+		if i != 2*n+1 {
+			t.Errorf("Expected failed index %d, got %d", 2*n+1, i)
+		}
+	}
+}
+
+func TestLessValErr(t *testing.T) {
+	specimen := ByMixedKeys{GenSpecimen(SPECIMEN_SIZE)}
+	// Mark one element to have a string key.
+	specimen.SpecimenSliceSorter[3].NotKey = -1
+
+	err := Keysort(specimen).Sort()
+
+	primingError, ok := err.(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError, got %v", err)
+	}
+	for _, err := range primingError.Errors {
+		if _, ok := err.(CompareError); !ok {
+			t.Errorf("Expected a CompareError, got %v", err)
+		}
+	}
+}
+
+func TestLenChanged(t *testing.T) {
+	specimen := &GrowableByIntKey{ByIntKey{GenSpecimen(SPECIMEN_SIZE)}}
+	ks := Keysort(specimen)
+
+	specimen.SpecimenSliceSorter = append(specimen.SpecimenSliceSorter, GenSpecimen(5)...)
+	err := ks.Sort()
+
+	if lenErr, ok := err.(LenChangedError); !ok {
+		t.Errorf("Expected a LenChangedError, got %v", err)
+	} else if lenErr.Expected != SPECIMEN_SIZE || lenErr.Actual != SPECIMEN_SIZE+5 {
+		t.Errorf("Unexpected lengths in %v", lenErr)
+	}
+	if !errors.Is(err, ErrLenMismatch) {
+		t.Errorf("Expected %v to match ErrLenMismatch", err)
+	}
+}
+
+func TestPrimedKeysortDescribed(t *testing.T) {
+	specimen := ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)}
+	described := []int{}
+
+	ks := PrimedKeysortDescribed(specimen, -1, func(i int) string {
+		described = append(described, i)
+		return fmt.Sprintf("user=%s", specimen.At(i).StringKey)
+	})
+
+	expected := "Problem pre-computing Key functions.\n\tindex 1 (user=aaa): Blah\n"
+	if err := ks.Errors(); err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+	if len(described) != 1 || described[0] != 1 {
+		t.Errorf("Expected only index 1 to be described, got %v", described)
+	}
+}
+
 func TestPrimedKeysortRetry(t *testing.T) {
 	innerSpecimen := GenSpecimen(SPECIMEN_SIZE)
 	specimen := ByStringKeyErrors{innerSpecimen}
@@ -119,6 +462,411 @@ func TestPrimedKeysortRetry(t *testing.T) {
 
 }
 
+// ByIntKeyFailsOnce returns garbage along with an error from the first call
+// to Key for the element whose NotKey is failAt, and its real key afterwards.
+type ByIntKeyFailsOnce struct {
+	ByIntKey
+	failAt int
+	failed *bool
+}
+
+func (s ByIntKeyFailsOnce) Key(i int) (interface{}, error) {
+	if s.At(i).NotKey == s.failAt && !*s.failed {
+		*s.failed = true
+		return "garbage", fmt.Errorf("Blah")
+	}
+	return s.ByIntKey.Key(i)
+}
+
+func newByIntKeyFailsOnce(failAt int) ByIntKeyFailsOnce {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	for i := range specimen {
+		specimen[i].NotKey = i
+	}
+	return ByIntKeyFailsOnce{ByIntKey{specimen}, failAt, new(bool)}
+}
+
+func TestFailedKeyNotMemoized(t *testing.T) {
+	specimen := newByIntKeyFailsOnce(3)
+	ks := PrimedKeysort(specimen, 1)
+
+	if _, computed := ks.Peek(3); computed {
+		t.Errorf("Expected the failed key not to be memoized")
+	}
+
+	// Comparing "garbage" would panic in LessVal, so the key must be
+	// recomputed rather than read back once its error is cleared.
+	ks.ClearErrors()
+	if err := ks.Sort(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !sort.IsSorted(specimen) {
+		t.Errorf("Failed to sort after clearing errors")
+	}
+	if ks.KeyCalls() != SPECIMEN_SIZE+1 {
+		t.Errorf("Expected only the failed key to be recomputed, got %d calls", ks.KeyCalls())
+	}
+}
+
+func TestRetryFailedRecomputes(t *testing.T) {
+	specimen := newByIntKeyFailsOnce(3)
+	ks := PrimedKeysort(specimen, 1)
+	// Move the failed element, so that its position differs from its original
+	// index.
+	ks.Swap(3, 0)
+
+	ks.RetryFailed(1)
+
+	if ks.Errors() != nil {
+		t.Errorf("No more errors expected, got %s", ks.Errors())
+	}
+	if value, computed := ks.Peek(3); !computed || value != specimen.At(0).IntKey {
+		t.Errorf("Expected the real key to be memoized, got %v, %t", value, computed)
+	}
+	if ks.KeyCalls() != SPECIMEN_SIZE+1 {
+		t.Errorf("Expected only the failed key to be recomputed, got %d calls", ks.KeyCalls())
+	}
+}
+
+func TestGoVet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go vet in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available")
+	}
+
+	if out, err := exec.Command(goTool, "vet", ".").CombinedOutput(); err != nil {
+		t.Errorf("go vet reported problems: %s\n%s", err, out)
+	}
+}
+
+func TestKeysortConcurrentKey(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	ks := Keysort(specimen)
+
+	wg := &sync.WaitGroup{}
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			for i := 0; i < SPECIMEN_SIZE; i++ {
+				ks.Key(i)
+			}
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < SPECIMEN_SIZE; i++ {
+		if ks.Key(i) != specimen.At(i).IntKey {
+			t.Errorf("Wrong key memoized for index %d", i)
+		}
+	}
+
+	sort.Sort(ks)
+	if !sort.IsSorted(specimen) {
+		t.Errorf("Keysort failed after concurrent Key calls")
+	}
+}
+
+func TestKeyConcurrentSameIndex(t *testing.T) {
+	specimen := &ByCallNumber{SpecimenSliceSorter: GenSpecimen(SPECIMEN_SIZE)}
+	ks := Keysort(specimen)
+
+	const index = 5
+	results := make(chan interface{}, 100)
+	wg := &sync.WaitGroup{}
+	for n := 0; n < cap(results); n++ {
+		wg.Add(1)
+		go func() {
+			results <- ks.Key(index)
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	first := <-results
+	for result := range results {
+		if result != first {
+			t.Errorf("Key(%d) returned both %v and %v", index, first, result)
+		}
+	}
+}
+
+func TestPrimedKeysortFailFast(t *testing.T) {
+	const size = 200
+	specimen := ByIntKeyHalfErrors{GenSpecimen(size)}
+
+	ks, err := PrimedKeysortFailFast(specimen, 2)
+
+	if err == nil {
+		t.Fatalf("Errors were expected.")
+	}
+	if ks.KeyCalls() > size/4 {
+		t.Errorf("Expected far fewer than %d key calls, got %d", size, ks.KeyCalls())
+	}
+
+	ks, err = PrimedKeysortFailFast(ByIntKey{GenSpecimen(size)}, 2)
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if ks.KeyCalls() != size {
+		t.Errorf("Expected %d key calls, got %d", size, ks.KeyCalls())
+	}
+}
+
+func TestPrimedKeysortOrdered(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	order := make([]int, SPECIMEN_SIZE)
+	for i := range order {
+		order[i] = SPECIMEN_SIZE - 1 - i
+	}
+
+	ks, err := PrimedKeysortOrdered(specimen, order, 1)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if memoized := countMemoized(ks); memoized != SPECIMEN_SIZE {
+		t.Errorf("Expected all %d keys memoized, got %d", SPECIMEN_SIZE, memoized)
+	}
+
+	for _, bad := range [][]int{order[1:], append([]int{0}, order[1:]...), append([]int{SPECIMEN_SIZE}, order[1:]...)} {
+		if _, err := PrimedKeysortOrdered(specimen, bad, 1); err == nil {
+			t.Errorf("Expected an error for order %v", bad)
+		}
+	}
+	if _, err := PrimedKeysortOrdered(specimen, order[1:], 1); !errors.Is(err, ErrLenMismatch) {
+		t.Errorf("Expected a short order to match ErrLenMismatch, got %v", err)
+	}
+}
+
+func TestMemoizeIndices(t *testing.T) {
+	calls := make([]int, SPECIMEN_SIZE)
+	ks := Keysort(CountedKeys{ByIntKey{GenSpecimen(SPECIMEN_SIZE)}, calls})
+
+	subset := map[int]bool{2: true, 3: true, 11: true}
+	ks.MemoizeIndices([]int{2, 3, 11, 3, 2, 3}, -1)
+
+	for i := 0; i < SPECIMEN_SIZE; i++ {
+		_, ok := ks.memo.Get(i)
+		if ok != subset[i] {
+			t.Errorf("Index %d memoized: %t, expected %t", i, ok, subset[i])
+		}
+		if subset[i] && calls[i] != 1 {
+			t.Errorf("Key(%d) called %d times, expected once", i, calls[i])
+		}
+	}
+}
+
+func TestErrorsConcurrentWithMemoize(t *testing.T) {
+	specimen := ByIntKeyHalfErrors{GenSpecimen(SPECIMEN_SIZE)}
+	ks := Keysort(specimen)
+
+	done := make(chan struct{})
+	go func() {
+		ks.memoize(-1, ks.allIndexes)
+		close(done)
+	}()
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			if err := ks.Errors(); err != nil {
+				// Mutating the returned map must not touch internal state.
+				for i := range err.(PrimingError).Errors {
+					delete(err.(PrimingError).Errors, i)
+				}
+			}
+		}
+	}
+
+	if err := ks.Errors(); err == nil {
+		t.Errorf("Errors were expected.")
+	} else if len(err.(PrimingError).Errors) != SPECIMEN_SIZE/2 {
+		t.Errorf("Expected exactly %d errors, got %d.", SPECIMEN_SIZE/2, len(err.(PrimingError).Errors))
+	}
+}
+
+func TestPrimedKeysortContextCancel(t *testing.T) {
+	specimen := ByIntKeySlow{GenSpecimen(SPECIMEN_SIZE), 10 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*specimen.delay)
+	defer cancel()
+
+	ks, err := PrimedKeysortContext(ctx, specimen, 1)
+
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if memoized := countMemoized(ks); memoized == 0 || memoized == SPECIMEN_SIZE {
+		t.Errorf("Expected partial memoization, got %d of %d keys", memoized, SPECIMEN_SIZE)
+	}
+
+	sort.Sort(ks)
+	if !sort.IsSorted(ByIntKey{specimen.SpecimenSliceSorter}) {
+		t.Errorf("Keysort failed after cancelled priming")
+	}
+}
+
+func TestPrimedKeysortContextUncancelled(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	ks, err := PrimedKeysortContext(context.Background(), specimen, -1)
+
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if memoized := countMemoized(ks); memoized != SPECIMEN_SIZE {
+		t.Errorf("Expected all %d keys memoized, got %d", SPECIMEN_SIZE, memoized)
+	}
+}
+
+func TestKeysortNil(t *testing.T) {
+	for name, construct := range map[string]func(){
+		"Keysort":       func() { Keysort(nil) },
+		"PrimedKeysort": func() { PrimedKeysort(nil, -1) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != "keysort: nil Interface" {
+					t.Errorf("%s(nil): expected a nil Interface panic, got %v", name, r)
+				}
+			}()
+			construct()
+		}()
+	}
+}
+
+func TestKeysortDeterministic(t *testing.T) {
+	// Large enough that sort.Sort does not just use insertion sort.
+	specimen := GenSpecimen(50 * SPECIMEN_SIZE)
+	for i := range specimen {
+		// Use NotKey to remember the input order, and force duplicate keys.
+		specimen[i].NotKey = i
+		specimen[i].IntKey = rand.Intn(3)
+	}
+	stable := append(SpecimenSliceSorter{}, specimen...)
+	sort.Stable(ByIntKey{stable})
+
+	for run := 0; run < 5; run++ {
+		sorted := append(SpecimenSliceSorter{}, specimen...)
+		sort.Sort(KeysortDeterministic(ByIntKey{sorted}))
+		for i := range sorted {
+			if sorted[i] != stable[i] {
+				t.Errorf("Run %d: expected %v at %d, got %v", run, stable[i], i, sorted[i])
+			}
+		}
+	}
+}
+
+func TestNilKeyError(t *testing.T) {
+	specimen := ByIntKeyNil{GenSpecimen(SPECIMEN_SIZE)}
+	for i := range specimen.SpecimenSliceSorter {
+		specimen.SpecimenSliceSorter[i].NotKey = i
+	}
+
+	ks := Keysort(specimen)
+	err := ks.Sort()
+
+	primingError, ok := err.(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError, got %v", err)
+	}
+	for _, i := range primingError.FailedIndices() {
+		if i%5 != 0 {
+			t.Errorf("Unexpected failure at %d", i)
+		}
+		if primingError.ErrorAt(i) != (NilKeyError{Index: i}) {
+			t.Errorf("Expected a NilKeyError at %d, got %v", i, primingError.ErrorAt(i))
+		}
+	}
+	if len(primingError.Errors) == 0 {
+		t.Errorf("Expected NilKeyErrors")
+	}
+}
+
+func TestKeysortIsolateErrors(t *testing.T) {
+	specimen := ByIntKeyHalfErrors{GenSpecimen(SPECIMEN_SIZE)}
+
+	ks := KeysortIsolateErrors(specimen)
+	primingError, ok := ks.Sort().(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError, got %v", ks.Errors())
+	}
+
+	// The elements that did not fail come first, in order.
+	failed := map[int]bool{}
+	for _, i := range primingError.FailedIndices() {
+		failed[i] = true
+	}
+	succeeded := SPECIMEN_SIZE - len(failed)
+	for i, originalIndex := range ks.SortedIndices() {
+		if (i >= succeeded) != failed[originalIndex] {
+			t.Errorf("Element originally at %d is in the wrong partition at %d", originalIndex, i)
+		}
+	}
+	if !sort.IsSorted(ByIntKey{specimen.SpecimenSliceSorter[:succeeded]}) {
+		t.Errorf("Elements that did not fail are out of order")
+	}
+}
+
+func TestDrainErrors(t *testing.T) {
+	ks := PrimedKeysort(ByIntKeyHalfErrors{GenSpecimen(SPECIMEN_SIZE)}, -1)
+	before := ks.Errors().(PrimingError)
+
+	drained, ok := ks.DrainErrors().(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError")
+	}
+	if len(drained.Errors) != len(before.Errors) {
+		t.Errorf("Expected %d drained errors, got %d", len(before.Errors), len(drained.Errors))
+	}
+	for i, err := range before.Errors {
+		if drained.Errors[i] != err {
+			t.Errorf("Expected %v at %d, got %v", err, i, drained.Errors[i])
+		}
+	}
+	if ks.Errors() != nil || ks.DrainErrors() != nil {
+		t.Errorf("Expected no errors after draining, got %v", ks.Errors())
+	}
+}
+
+func TestDrainErrorsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ks := KeysortContext(ctx, ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)})
+	ks.Key(1)
+	sort.Sort(ks)
+
+	// The cancelled context is reported first, as by Errors, and draining
+	// leaves it in place.
+	for n := 0; n < 2; n++ {
+		if err := ks.DrainErrors(); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled from DrainErrors, got %v", err)
+		}
+	}
+	if err := ks.Errors(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from Errors after draining, got %v", err)
+	}
+}
+
+// countMemoized returns how many keys ks has memoized.
+func countMemoized(ks *keySortable) int {
+	ks.Lock()
+	defer ks.Unlock()
+	count := 0
+	for i := 0; i < ks.Len(); i++ {
+		if _, ok := ks.memo.Get(i); ok {
+			count++
+		}
+	}
+	return count
+}
+
 type SpecimenSliceSorter []ExampleToSort
 
 func (s SpecimenSliceSorter) Len() int {
@@ -163,7 +911,7 @@ func (s ByStringKey) Key(i int) (interface{}, error) {
 
 type ByIntKeyCounted struct {
 	SpecimenSliceSorter
-	count int
+	count *int64
 }
 
 func (s ByIntKeyCounted) LessVal(i, j interface{}) bool {
@@ -171,7 +919,7 @@ func (s ByIntKeyCounted) LessVal(i, j interface{}) bool {
 }
 
 func (s ByIntKeyCounted) Key(i int) (interface{}, error) {
-	s.count++
+	atomic.AddInt64(s.count, 1)
 	return s.At(i).IntKey, nil
 }
 
@@ -189,3 +937,88 @@ func (s ByStringKeyErrors) Key(i int) (interface{}, error) {
 	}
 	return s.At(i).StringKey, nil
 }
+
+// Only implements keysort.Interface. Returns an error for every odd index.
+type ByIntKeyHalfErrors struct{ SpecimenSliceSorter }
+
+func (s ByIntKeyHalfErrors) LessVal(i, j interface{}) bool {
+	return i.(int) < j.(int)
+}
+
+func (s ByIntKeyHalfErrors) Key(i int) (interface{}, error) {
+	if i%2 == 1 {
+		return nil, fmt.Errorf("odd index %d", i)
+	}
+	return s.At(i).IntKey, nil
+}
+
+// ByIntKeyNil returns a nil key when NotKey is a multiple of five.
+type ByIntKeyNil struct{ SpecimenSliceSorter }
+
+func (s ByIntKeyNil) LessVal(i, j interface{}) bool {
+	return i.(int) < j.(int)
+}
+
+func (s ByIntKeyNil) Key(i int) (interface{}, error) {
+	if s.At(i).NotKey%5 == 0 {
+		return nil, nil
+	}
+	return s.At(i).IntKey, nil
+}
+
+// ByIntKeySlow sleeps for delay before returning each key.
+type ByIntKeySlow struct {
+	SpecimenSliceSorter
+	delay time.Duration
+}
+
+func (s ByIntKeySlow) LessVal(i, j interface{}) bool {
+	return i.(int) < j.(int)
+}
+
+func (s ByIntKeySlow) Key(i int) (interface{}, error) {
+	time.Sleep(s.delay)
+	return s.At(i).IntKey, nil
+}
+
+// ByCallNumber returns a different key on every call to Key().
+type ByCallNumber struct {
+	SpecimenSliceSorter
+	calls int64
+}
+
+func (s *ByCallNumber) LessVal(i, j interface{}) bool {
+	return i.(int64) < j.(int64)
+}
+
+func (s *ByCallNumber) Key(i int) (interface{}, error) {
+	return atomic.AddInt64(&s.calls, 1), nil
+}
+
+// ByMixedKeys returns a string key for index 0 and int keys otherwise, and
+// implements LessValErr to reject comparisons between the two.
+type ByMixedKeys struct{ SpecimenSliceSorter }
+
+func (s ByMixedKeys) LessVal(i, j interface{}) bool {
+	panic("LessVal should not be called when LessValE is implemented")
+}
+
+func (s ByMixedKeys) LessValE(i, j interface{}) (bool, error) {
+	iInt, iOk := i.(int)
+	jInt, jOk := j.(int)
+	if !iOk || !jOk {
+		return false, fmt.Errorf("cannot compare %T with %T", i, j)
+	}
+	return iInt < jInt, nil
+}
+
+func (s ByMixedKeys) Key(i int) (interface{}, error) {
+	if s.At(i).NotKey == -1 {
+		return s.At(i).StringKey, nil
+	}
+	return s.At(i).IntKey, nil
+}
+
+// GrowableByIntKey is a ByIntKey whose length can change after it has been
+// wrapped.
+type GrowableByIntKey struct{ ByIntKey }
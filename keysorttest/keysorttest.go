@@ -0,0 +1,58 @@
+// Package keysorttest provides helpers for testing code built on keysort.
+package keysorttest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/danverbraganza/keysort"
+)
+
+// AssertKeyCalledOnce sorts wrapped with keysort, and reports an error on t
+// for each element whose Key was called more than once. Elements are tracked
+// as they are swapped, so the count is per element rather than per position.
+func AssertKeyCalledOnce(t testing.TB, wrapped keysort.Interface) {
+	t.Helper()
+	assertKeyCalledOnce(t, wrapped, func(counted keysort.Interface) {
+		keysort.Keysort(counted).Sort()
+	})
+}
+
+// assertKeyCalledOnce is AssertKeyCalledOnce, sorting with sortWith.
+func assertKeyCalledOnce(t testing.TB, wrapped keysort.Interface, sortWith func(keysort.Interface)) {
+	t.Helper()
+	c := &counted{Interface: wrapped, ids: make([]int, wrapped.Len()), calls: make([]int, wrapped.Len())}
+	for i := range c.ids {
+		c.ids[i] = i
+	}
+
+	sortWith(c)
+
+	for id, calls := range c.calls {
+		if calls > 1 {
+			t.Errorf("Key was called %d times for the element originally at %d", calls, id)
+		}
+	}
+}
+
+// counted counts the calls to Key for each element of an Interface.
+type counted struct {
+	keysort.Interface
+	// ids holds the original index of the element at each position.
+	ids []int
+	// lock guards calls, since keys may be computed concurrently.
+	lock  sync.Mutex
+	calls []int
+}
+
+func (c *counted) Key(i int) (interface{}, error) {
+	c.lock.Lock()
+	c.calls[c.ids[i]]++
+	c.lock.Unlock()
+	return c.Interface.Key(i)
+}
+
+func (c *counted) Swap(i, j int) {
+	c.ids[i], c.ids[j] = c.ids[j], c.ids[i]
+	c.Interface.Swap(i, j)
+}
@@ -0,0 +1,65 @@
+package keysorttest
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/danverbraganza/keysort"
+)
+
+// Words sorts strings by length.
+type Words []string
+
+func (w Words) LessVal(i, j interface{}) bool  { return i.(int) < j.(int) }
+func (w Words) Key(i int) (interface{}, error) { return len(w[i]), nil }
+func (w Words) Swap(i, j int)                  { w[i], w[j] = w[j], w[i] }
+func (w Words) Len() int                       { return len(w) }
+
+// recorder is a testing.TB that records errors instead of failing.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// unmemoized sorts an Interface by calling Key on every comparison.
+type unmemoized struct{ keysort.Interface }
+
+func (u unmemoized) Less(i, j int) bool {
+	a, _ := u.Key(i)
+	b, _ := u.Key(j)
+	return u.LessVal(a, b)
+}
+
+func TestAssertKeyCalledOnce(t *testing.T) {
+	words := Words{"ccc", "a", "dddd", "bb", "eeeee"}
+	r := &recorder{TB: t}
+
+	AssertKeyCalledOnce(r, words)
+
+	if len(r.errors) != 0 {
+		t.Errorf("Expected no errors, got %v", r.errors)
+	}
+	if !sort.SliceIsSorted(words, func(i, j int) bool { return len(words[i]) < len(words[j]) }) {
+		t.Errorf("Expected words to be sorted, got %v", words)
+	}
+}
+
+func TestAssertKeyCalledOnceFails(t *testing.T) {
+	words := Words{"ccc", "a", "dddd", "bb", "eeeee"}
+	r := &recorder{TB: t}
+
+	assertKeyCalledOnce(r, words, func(counted keysort.Interface) {
+		sort.Sort(unmemoized{counted})
+	})
+
+	if len(r.errors) == 0 {
+		t.Errorf("Expected errors for a sort that does not memoize keys")
+	}
+}
@@ -0,0 +1,34 @@
+package keysort
+
+// A KeysortPool limits how many calls to Key may be in flight at once across
+// every keySortable created from it, however many of them are primed or sorted
+// concurrently. It is safe for concurrent use.
+type KeysortPool struct {
+	sem chan struct{}
+}
+
+// NewKeysortPool creates a KeysortPool that allows at most maxConcurrency
+// concurrent calls to Key. It panics if maxConcurrency is less than one.
+func NewKeysortPool(maxConcurrency int) *KeysortPool {
+	if maxConcurrency < 1 {
+		panic("keysort: NewKeysortPool needs a positive maxConcurrency")
+	}
+	return &KeysortPool{make(chan struct{}, maxConcurrency)}
+}
+
+// Keysort is like the package-level Keysort, but keys computed while sorting
+// count against the pool's limit.
+func (p *KeysortPool) Keysort(wrapped Interface) *keySortable {
+	ks := Keysort(wrapped)
+	ks.sem = p.sem
+	return ks
+}
+
+// PrimedKeysort is like the package-level PrimedKeysort, but waits for the
+// pool to allow each call to Key. parallelism still bounds the goroutines of
+// this one keySortable.
+func (p *KeysortPool) PrimedKeysort(wrapped Interface, parallelism int) *keySortable {
+	ks := p.Keysort(wrapped)
+	ks.memoize(parallelism, ks.allIndexes)
+	return ks
+}
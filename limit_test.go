@@ -0,0 +1,69 @@
+package keysort
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ByIntKeyConcurrency tracks how many calls to Key are running at once, and
+// the most that ever were.
+type ByIntKeyConcurrency struct {
+	SpecimenSliceSorter
+	running, max *int64
+}
+
+func (s ByIntKeyConcurrency) LessVal(i, j interface{}) bool {
+	return i.(int) < j.(int)
+}
+
+func (s ByIntKeyConcurrency) Key(i int) (interface{}, error) {
+	running := atomic.AddInt64(s.running, 1)
+	defer atomic.AddInt64(s.running, -1)
+	for {
+		max := atomic.LoadInt64(s.max)
+		if running <= max || atomic.CompareAndSwapInt64(s.max, max, running) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return s.At(i).IntKey, nil
+}
+
+func TestKeysortPoolLimit(t *testing.T) {
+	const maxConcurrency = 3
+	pool := NewKeysortPool(maxConcurrency)
+	var running, max int64
+
+	wg := sync.WaitGroup{}
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			specimen := ByIntKeyConcurrency{GenSpecimen(SPECIMEN_SIZE), &running, &max}
+			sort.Sort(pool.PrimedKeysort(specimen, 4))
+			if !sort.IsSorted(ByIntKey{specimen.SpecimenSliceSorter}) {
+				t.Errorf("KeysortPool failed to sort")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if max > maxConcurrency {
+		t.Errorf("Expected at most %d concurrent Key calls, saw %d", maxConcurrency, max)
+	}
+	if max == 0 {
+		t.Errorf("Expected Key to be called")
+	}
+}
+
+func TestNewKeysortPoolInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected NewKeysortPool(0) to panic")
+		}
+	}()
+	NewKeysortPool(0)
+}
@@ -0,0 +1,38 @@
+package keysort
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// MapEntry is a key-value pair of a map sorted by KeysortMap.
+type MapEntry struct {
+	Key, Value interface{}
+}
+
+// KeysortMap returns the entries of the map m, sorted by the keys that key
+// computes from each of them, compared with less. key is called once per
+// entry. An error is returned if m is not a map, or if key fails for any
+// entry, in which case the order of the entries cannot be trusted.
+func KeysortMap(m interface{}, key func(k, v interface{}) (interface{}, error), less Comparator) ([]MapEntry, error) {
+	value := reflect.ValueOf(m)
+	if value.Kind() != reflect.Map {
+		return nil, fmt.Errorf("keysort: KeysortMap needs a map, got %T", m)
+	}
+
+	entries := make([]MapEntry, 0, value.Len())
+	for iter := value.MapRange(); iter.Next(); {
+		entries = append(entries, MapEntry{iter.Key().Interface(), iter.Value().Interface()})
+	}
+
+	err := KeysortFunc(len(entries),
+		func(i int) (interface{}, error) {
+			return key(entries[i].Key, entries[i].Value)
+		},
+		less,
+		func(i, j int) {
+			entries[i], entries[j] = entries[j], entries[i]
+		},
+	).Sort()
+	return entries, err
+}
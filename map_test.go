@@ -0,0 +1,54 @@
+package keysort
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestKeysortMap(t *testing.T) {
+	m := map[string]int{"a": 3, "b": -5, "c": 1, "d": -2}
+	calls := 0
+
+	entries, err := KeysortMap(m,
+		// Sort by distance from zero.
+		func(k, v interface{}) (interface{}, error) {
+			calls++
+			if v.(int) < 0 {
+				return -v.(int), nil
+			}
+			return v.(int), nil
+		},
+		func(a, b interface{}) bool { return a.(int) < b.(int) })
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []MapEntry{{"c", 1}, {"d", -2}, {"a", 3}, {"b", -5}}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, entries)
+	}
+	for i := range expected {
+		if entries[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, entries)
+			break
+		}
+	}
+	if calls != len(m) {
+		t.Errorf("Expected %d key calls, got %d", len(m), calls)
+	}
+}
+
+func TestKeysortMapErrors(t *testing.T) {
+	less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+
+	if _, err := KeysortMap([]int{1}, nil, less); err == nil {
+		t.Errorf("Expected an error for a slice")
+	}
+	_, err := KeysortMap(map[string]int{"a": 1, "b": 2},
+		func(k, v interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("failed")
+		}, less)
+	if err == nil {
+		t.Errorf("Expected key errors")
+	}
+}
@@ -0,0 +1,50 @@
+package keysort
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalMemo encodes every successfully memoized key as JSON, by the current
+// position of its element, so that LoadMemo can restore them for the container
+// as it is now. Keys whose computation failed are left out. The keys must be
+// encodable by encoding/json.
+func (ks *keySortable) MarshalMemo() ([]byte, error) {
+	ks.Lock()
+	keys := map[int]interface{}{}
+	for i, originalIndex := range ks.swaps {
+		if _, failed := ks.errors[originalIndex]; failed {
+			continue
+		}
+		if value, ok := ks.memo.Get(originalIndex); ok {
+			keys[i] = value
+		}
+	}
+	ks.Unlock()
+	return json.Marshal(keys)
+}
+
+// LoadMemo creates a keySortable over wrapped whose memo is restored from data,
+// as produced by MarshalMemo. Since JSON does not record concrete types, each
+// key is turned back into the type that LessVal expects by decode. An error is
+// returned if data cannot be parsed, if decode fails, or if data holds a key
+// for a position that wrapped does not have.
+func LoadMemo(wrapped Interface, data []byte, decode func(json.RawMessage) (interface{}, error)) (*keySortable, error) {
+	var raw map[int]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	ks := Keysort(wrapped)
+	for i, message := range raw {
+		if i < 0 || i >= ks.Len() {
+			return nil, fmt.Errorf("keysort: memo has a key for index %d, but Len() is %d", i, ks.Len())
+		}
+		value, err := decode(message)
+		if err != nil {
+			return nil, fmt.Errorf("keysort: decoding key %d: %w", i, err)
+		}
+		ks.memo.Put(i, value)
+	}
+	return ks, nil
+}
@@ -0,0 +1,70 @@
+package keysort
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+)
+
+func decodeInt(message json.RawMessage) (interface{}, error) {
+	var value int
+	err := json.Unmarshal(message, &value)
+	return value, err
+}
+
+func decodeString(message json.RawMessage) (interface{}, error) {
+	var value string
+	err := json.Unmarshal(message, &value)
+	return value, err
+}
+
+func TestMarshalMemoRoundTrip(t *testing.T) {
+	for name, test := range map[string]struct {
+		wrapped func(SpecimenSliceSorter) Interface
+		decode  func(json.RawMessage) (interface{}, error)
+	}{
+		"int": {
+			func(s SpecimenSliceSorter) Interface { return ByIntKey{s} },
+			decodeInt,
+		},
+		"string": {
+			func(s SpecimenSliceSorter) Interface { return ByStringKey{s} },
+			decodeString,
+		},
+	} {
+		specimen := GenSpecimen(SPECIMEN_SIZE)
+		ks := PrimedKeysort(test.wrapped(specimen), -1)
+		data, err := ks.MarshalMemo()
+		if err != nil {
+			t.Fatalf("%s: unexpected error marshalling: %s", name, err)
+		}
+
+		var count int64
+		counted := ByIntKeyCounted{specimen, &count}
+		loaded, err := LoadMemo(withComparator{counted, test.wrapped(specimen).LessVal}, data, test.decode)
+		if err != nil {
+			t.Fatalf("%s: unexpected error loading: %s", name, err)
+		}
+		sort.Sort(loaded)
+
+		if count != 0 {
+			t.Errorf("%s: expected no keys to be recomputed, got %d", name, count)
+		}
+		if !sort.IsSorted(test.wrapped(specimen).(sort.Interface)) {
+			t.Errorf("%s: sort with loaded memo failed", name)
+		}
+	}
+}
+
+func TestLoadMemoErrors(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	for name, data := range map[string]string{
+		"malformed":    `{`,
+		"out of range": `{"20": 1}`,
+		"wrong type":   `{"0": "a"}`,
+	} {
+		if _, err := LoadMemo(specimen, []byte(data), decodeInt); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
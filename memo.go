@@ -0,0 +1,210 @@
+package keysort
+
+import "sync/atomic"
+
+// Memo stores the memoized keys of a keySortable, by the original index of
+// each element. Calls to Get and Put are serialized by the keySortable, so a
+// Memo need not be safe for concurrent use.
+//
+// A Memo is free to forget values it was given, for instance to bound its
+// memory use; the key is then simply recomputed on its next use.
+type Memo interface {
+	Get(origIndex int) (value interface{}, ok bool)
+	Put(origIndex int, value interface{})
+}
+
+// KeysortWithMemo is like Keysort, but memoizes keys in m rather than in the
+// built-in memo. It panics if wrapped is nil.
+func KeysortWithMemo(wrapped Interface, m Memo) *keySortable {
+	if wrapped == nil {
+		panic("keysort: nil Interface")
+	}
+	wrappedLen := wrapped.Len()
+	swaps := make([]int, wrappedLen)
+	for i := 0; i < wrappedLen; i++ {
+		swaps[i] = i
+	}
+
+	return &keySortable{
+		wrapped: wrapped,
+		memo:    m,
+		errors:  make(map[int]error, errorsCapacity(wrappedLen)),
+		swaps:   swaps,
+		guard:   findGuard(wrapped),
+	}
+}
+
+// errorsCapacity is the initial capacity of the errors map of a container of
+// size elements. Errors are expected to be rare, so it is much smaller than
+// size, but it saves the map from growing many times when they are not.
+func errorsCapacity(size int) int {
+	return min(size/16, 1024)
+}
+
+// memoCell holds the memoized result of a single call to wrapped.Key().
+type memoCell struct {
+	// computed is true once Key() has been called for this cell.
+	computed bool
+	value    interface{}
+}
+
+// sliceMemo is the built-in Memo. Since original indices are always dense, it
+// is a preallocated slice rather than a map.
+type sliceMemo struct {
+	cells []memoCell
+}
+
+// newSliceMemo returns a sliceMemo with room for size keys.
+func newSliceMemo(size int) *sliceMemo {
+	return &sliceMemo{make([]memoCell, size)}
+}
+
+// Get returns the value memoized for origIndex, if there is one.
+func (m *sliceMemo) Get(origIndex int) (interface{}, bool) {
+	cell := m.cells[origIndex]
+	return cell.value, cell.computed
+}
+
+// Put memoizes value for origIndex.
+func (m *sliceMemo) Put(origIndex int, value interface{}) {
+	m.cells[origIndex] = memoCell{computed: true, value: value}
+}
+
+// forget drops the value memoized for origIndex.
+func (m *sliceMemo) forget(origIndex int) {
+	m.cells[origIndex] = memoCell{}
+}
+
+// MemoSnapshot returns a copy of every memoized key, by the original index of
+// its element. Changing the returned map does not affect the keySortable.
+func (ks *keySortable) MemoSnapshot() map[int]interface{} {
+	ks.Lock()
+	defer ks.Unlock()
+	snapshot := map[int]interface{}{}
+	for i := 0; i < ks.Len(); i++ {
+		if value, ok := ks.memo.Get(i); ok {
+			snapshot[i] = value
+		}
+	}
+	return snapshot
+}
+
+// Peek returns the key memoized for the element at origIndex, its original
+// index, and whether there is one. Unlike Key(), it never calls wrapped.Key().
+func (ks *keySortable) Peek(origIndex int) (value interface{}, computed bool) {
+	if origIndex < 0 || origIndex >= ks.Len() {
+		return nil, false
+	}
+	ks.Lock()
+	defer ks.Unlock()
+	return ks.memo.Get(origIndex)
+}
+
+// PrimedCount returns how many keys are currently memoized, including those
+// whose computation failed.
+func (ks *keySortable) PrimedCount() int {
+	ks.Lock()
+	defer ks.Unlock()
+	count := 0
+	for i := 0; i < ks.Len(); i++ {
+		if _, ok := ks.lookupKey(i); ok {
+			count++
+		}
+	}
+	return count
+}
+
+// RemainingUnprimed returns how many keys are not yet memoized, which is how
+// many more calls to wrapped.Key() a full sort will make, unless the memo
+// forgets keys as KeysortCapped's does.
+func (ks *keySortable) RemainingUnprimed() int {
+	return ks.Len() - ks.PrimedCount()
+}
+
+// EstimateKeyCalls returns how many calls to wrapped.Key() a primed keysort of
+// wrapped will make, without calling it. Each key is memoized the first time
+// it is computed, so this is Len().
+//
+// An unprimed sort makes the same number of calls, since every element must
+// be compared at least once, except that a container of a single element
+// needs none. A memo that forgets keys can make the sort recompute them, and
+// KeysortBudget may stop before computing them all.
+func EstimateKeyCalls(wrapped Interface) int {
+	return wrapped.Len()
+}
+
+// FullyPrimed reports whether every key is memoized and none has failed, so
+// that sorting will not need to call wrapped.Key() at all.
+func (ks *keySortable) FullyPrimed() bool {
+	return ks.PrimedCount() == ks.Len() && ks.Errors() == nil
+}
+
+// CloneFor creates a keySortable over wrapped that starts with the keys
+// already memoized by ks, so that a copy of the container can be sorted
+// without recomputing them. wrapped must hold the same elements, in the same
+// order, as the container ks currently wraps. A LenChangedError is returned if
+// it has a different length.
+func (ks *keySortable) CloneFor(wrapped Interface) (*keySortable, error) {
+	if wrapped.Len() != ks.Len() {
+		return nil, LenChangedError{Expected: ks.Len(), Actual: wrapped.Len()}
+	}
+
+	clone := Keysort(wrapped)
+	clone.timeout = ks.timeout
+	clone.ctx = ks.ctx
+	clone.retry = ks.retry
+
+	ks.Lock()
+	defer ks.Unlock()
+	// The element now at position i of wrapped was originally at ks.swaps[i].
+	for i, originalIndex := range ks.swaps {
+		if value, ok := ks.memo.Get(originalIndex); ok {
+			clone.memo.Put(i, value)
+		}
+		if err, ok := ks.errors[originalIndex]; ok {
+			clone.errors[i] = err
+		}
+	}
+	return clone, nil
+}
+
+// Reset returns ks to the state it had when it was created, as if over the
+// container as it is now: every memoized key and error is dropped, and the
+// element at each position is treated as being at its original index. The
+// existing allocations are reused when the length of wrapped is unchanged, so
+// a keySortable can be primed and sorted many times without much garbage.
+//
+// A Memo passed to KeysortWithMemo is cleared by calling its Reset method,
+// if it has one; otherwise it is replaced by the built-in memo.
+func (ks *keySortable) Reset() {
+	ks.Lock()
+	defer ks.Unlock()
+
+	n := ks.wrapped.Len()
+	if n != len(ks.swaps) {
+		ks.swaps = make([]int, n)
+	}
+	for i := range ks.swaps {
+		ks.swaps[i] = i
+	}
+
+	switch memo := ks.memo.(type) {
+	case *sliceMemo:
+		if len(memo.cells) == n {
+			clear(memo.cells)
+		} else {
+			memo.cells = make([]memoCell, n)
+		}
+	case interface{ Reset() }:
+		memo.Reset()
+	default:
+		ks.memo = newSliceMemo(n)
+	}
+
+	clear(ks.errors)
+	ks.lenErr = nil
+	ks.genErr = nil
+	ks.ctxErr = nil
+	atomic.StoreInt64(&ks.keyCalls, 0)
+	atomic.StoreInt64(&ks.comparisons, 0)
+}
@@ -0,0 +1,215 @@
+package keysort
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestKeysortWithMemo(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	memo := &CountingMemo{values: map[int]interface{}{}}
+
+	sort.Sort(KeysortWithMemo(specimen, memo))
+
+	if !sort.IsSorted(specimen) {
+		t.Errorf("KeysortWithMemo failed for ByIntKey")
+	}
+	if memo.puts != SPECIMEN_SIZE {
+		t.Errorf("Expected %d Puts, got %d", SPECIMEN_SIZE, memo.puts)
+	}
+	if memo.gets == 0 {
+		t.Errorf("Expected the sorter to Get keys from the memo")
+	}
+	for i := range specimen.SpecimenSliceSorter {
+		if _, ok := memo.values[i]; !ok {
+			t.Errorf("Key for index %d was not routed through the memo", i)
+		}
+	}
+}
+
+// CountingMemo is a map-backed Memo that counts its calls.
+type CountingMemo struct {
+	values     map[int]interface{}
+	gets, puts int
+}
+
+func (m *CountingMemo) Get(origIndex int) (interface{}, bool) {
+	m.gets++
+	value, ok := m.values[origIndex]
+	return value, ok
+}
+
+func (m *CountingMemo) Put(origIndex int, value interface{}) {
+	m.puts++
+	m.values[origIndex] = value
+}
+
+func TestMemoSnapshot(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	original := append(SpecimenSliceSorter{}, specimen.SpecimenSliceSorter...)
+	ks := PrimedKeysort(specimen, -1)
+	sort.Sort(ks)
+
+	snapshot := ks.MemoSnapshot()
+
+	if len(snapshot) != SPECIMEN_SIZE {
+		t.Errorf("Expected %d keys in the snapshot, got %d", SPECIMEN_SIZE, len(snapshot))
+	}
+	for i, element := range original {
+		if snapshot[i] != element.IntKey {
+			t.Errorf("Expected key %d for index %d, got %v", element.IntKey, i, snapshot[i])
+		}
+	}
+
+	delete(snapshot, 0)
+	if _, ok := ks.memo.Get(0); !ok {
+		t.Errorf("Changing the snapshot changed the memo")
+	}
+}
+
+func TestCloneFor(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	ks := PrimedKeysort(specimen, -1)
+	// Shuffle the container through ks, so the memo must follow the swaps.
+	rand.Shuffle(SPECIMEN_SIZE, ks.Swap)
+
+	copied := ByIntKey{append(SpecimenSliceSorter{}, specimen.SpecimenSliceSorter...)}
+	clone, err := ks.CloneFor(copied)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	sort.Sort(clone)
+
+	if !sort.IsSorted(copied) {
+		t.Errorf("CloneFor failed to sort the copy")
+	}
+	if clone.KeyCalls() != 0 {
+		t.Errorf("Expected no new Key calls, got %d", clone.KeyCalls())
+	}
+
+	if _, err := ks.CloneFor(ByIntKey{GenSpecimen(SPECIMEN_SIZE + 1)}); err == nil {
+		t.Errorf("Expected an error for a container of a different length")
+	}
+}
+
+func TestReset(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	ks := PrimedKeysort(specimen, -1)
+	sort.Sort(ks)
+
+	ks.Reset()
+
+	if memoized := countMemoized(ks); memoized != 0 {
+		t.Errorf("Expected no memoized keys after Reset, got %d", memoized)
+	}
+	if ks.KeyCalls() != 0 || ks.Comparisons() != 0 {
+		t.Errorf("Expected counters to be reset, got %d and %d", ks.KeyCalls(), ks.Comparisons())
+	}
+	for i, originalIndex := range ks.SortedIndices() {
+		if i != originalIndex {
+			t.Errorf("Expected identity permutation after Reset, got %v", ks.SortedIndices())
+			break
+		}
+	}
+
+	// Shuffle the container, and check that it is sorted afresh.
+	copy(specimen.SpecimenSliceSorter, GenSpecimen(SPECIMEN_SIZE))
+	ks.memoize(-1, ks.allIndexes)
+	sort.Sort(ks)
+	if !sort.IsSorted(specimen) {
+		t.Errorf("Sort failed after Reset")
+	}
+	if calls := ks.KeyCalls(); calls != SPECIMEN_SIZE {
+		t.Errorf("Expected %d Key calls after Reset, got %d", SPECIMEN_SIZE, calls)
+	}
+}
+
+func TestResetCustomMemo(t *testing.T) {
+	specimen := ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)}
+	ks := KeysortWithMemo(specimen, &CountingMemo{values: map[int]interface{}{}})
+	sort.Sort(ks)
+
+	ks.Reset()
+
+	if ks.Errors() != nil {
+		t.Errorf("Expected no errors after Reset, got %s", ks.Errors())
+	}
+	if memoized := countMemoized(ks); memoized != 0 {
+		t.Errorf("Expected no memoized keys after Reset, got %d", memoized)
+	}
+}
+
+func TestPrimedCount(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	ks := Keysort(specimen)
+	if ks.PrimedCount() != 0 || ks.FullyPrimed() {
+		t.Errorf("Expected a new keySortable to be unprimed, got %d", ks.PrimedCount())
+	}
+
+	ks.MemoizeIndices([]int{0, 1, 2}, -1)
+	if ks.PrimedCount() != 3 || ks.FullyPrimed() {
+		t.Errorf("Expected 3 primed keys, got %d", ks.PrimedCount())
+	}
+
+	ks.memoize(-1, ks.allIndexes)
+	if ks.PrimedCount() != SPECIMEN_SIZE || !ks.FullyPrimed() {
+		t.Errorf("Expected all %d keys primed, got %d", SPECIMEN_SIZE, ks.PrimedCount())
+	}
+}
+
+func TestPrimedCountErrors(t *testing.T) {
+	ks := PrimedKeysort(ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)}, -1)
+
+	if ks.PrimedCount() != SPECIMEN_SIZE {
+		t.Errorf("Expected %d primed keys, got %d", SPECIMEN_SIZE, ks.PrimedCount())
+	}
+	if ks.FullyPrimed() {
+		t.Errorf("Expected a keySortable with errors not to be fully primed")
+	}
+}
+
+func TestPeek(t *testing.T) {
+	ks := Keysort(ByIntKey{GenSpecimen(SPECIMEN_SIZE)})
+	if _, computed := ks.Peek(0); computed {
+		t.Errorf("Expected no key to be computed before priming")
+	}
+	if ks.KeyCalls() != 0 {
+		t.Errorf("Expected Peek not to call Key, got %d calls", ks.KeyCalls())
+	}
+
+	ks.memoize(-1, ks.allIndexes)
+	sort.Sort(ks)
+	// The element originally at index 0 has IntKey 1.
+	if value, computed := ks.Peek(0); !computed || value != 1 {
+		t.Errorf("Expected Peek(0) to return 1, got %v, %t", value, computed)
+	}
+	if _, computed := ks.Peek(SPECIMEN_SIZE); computed {
+		t.Errorf("Expected Peek out of range to report nothing computed")
+	}
+}
+
+func TestRemainingUnprimed(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	if EstimateKeyCalls(specimen) != SPECIMEN_SIZE {
+		t.Errorf("Expected an estimate of %d, got %d", SPECIMEN_SIZE, EstimateKeyCalls(specimen))
+	}
+
+	ks := Keysort(specimen)
+	if ks.RemainingUnprimed() != SPECIMEN_SIZE {
+		t.Errorf("Expected %d unprimed keys, got %d", SPECIMEN_SIZE, ks.RemainingUnprimed())
+	}
+
+	ks.MemoizeIndices([]int{0, 1, 2}, -1)
+	if ks.RemainingUnprimed() != SPECIMEN_SIZE-3 {
+		t.Errorf("Expected %d unprimed keys, got %d", SPECIMEN_SIZE-3, ks.RemainingUnprimed())
+	}
+
+	sort.Sort(ks)
+	if ks.RemainingUnprimed() != 0 {
+		t.Errorf("Expected no unprimed keys after sorting, got %d", ks.RemainingUnprimed())
+	}
+	if ks.KeyCalls() != EstimateKeyCalls(specimen) {
+		t.Errorf("Expected %d key calls as estimated, got %d", EstimateKeyCalls(specimen), ks.KeyCalls())
+	}
+}
@@ -0,0 +1,41 @@
+package keysort
+
+import "runtime/debug"
+
+// KeyNormalizer may optionally be implemented by an Interface whose Key
+// returns values of several types, for instance a mix of int and float64. If
+// it is, Normalize is applied to every key before it is memoized, so that
+// LessVal only ever sees normalized keys. Any error it returns is recorded
+// against the element, as for an error from Key.
+type KeyNormalizer interface {
+	Normalize(key interface{}) (interface{}, error)
+}
+
+// finishKey checks a key just computed for the element currently at index i.
+// Unless it failed, it is normalized if wrapped is a KeyNormalizer, and a
+// NilKeyError is returned if it is nil.
+func (ks *keySortable) finishKey(i int, value interface{}, err error) (interface{}, error) {
+	if err != nil {
+		return value, err
+	}
+	if normalizer, ok := optional[KeyNormalizer](ks.wrapped); ok {
+		if value, err = ks.safeNormalize(i, normalizer, value); err != nil {
+			return value, err
+		}
+	}
+	if value == nil {
+		return nil, NilKeyError{Index: ks.swaps[i]}
+	}
+	return value, nil
+}
+
+// safeNormalize normalizes the key of the element currently at index i,
+// converting a panic into a KeyPanicError.
+func (ks *keySortable) safeNormalize(i int, normalizer KeyNormalizer, key interface{}) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			value, err = nil, KeyPanicError{Index: ks.swaps[i], Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return normalizer.Normalize(key)
+}
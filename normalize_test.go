@@ -0,0 +1,86 @@
+package keysort
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// MixedNumbers has keys that are ints, float64s, or something unexpected.
+type MixedNumbers []interface{}
+
+func (s MixedNumbers) LessVal(i, j interface{}) bool  { return i.(float64) < j.(float64) }
+func (s MixedNumbers) Key(i int) (interface{}, error) { return s[i], nil }
+func (s MixedNumbers) Swap(i, j int)                  { s[i], s[j] = s[j], s[i] }
+func (s MixedNumbers) Len() int                       { return len(s) }
+
+func (s MixedNumbers) Normalize(key interface{}) (interface{}, error) {
+	switch key := key.(type) {
+	case int:
+		return float64(key), nil
+	case float64:
+		return key, nil
+	}
+	return nil, fmt.Errorf("cannot normalize %v", key)
+}
+
+func TestKeyNormalizer(t *testing.T) {
+	specimen := MixedNumbers{3, 1.5, 2, 0.5, 4.25, 1}
+
+	ks := Keysort(specimen)
+	if err := ks.Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := MixedNumbers{0.5, 1, 1.5, 2, 3, 4.25}
+	for i := range expected {
+		if specimen[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, specimen)
+			break
+		}
+	}
+}
+
+func TestKeyNormalizerErrors(t *testing.T) {
+	specimen := MixedNumbers{3, "two", 1.5}
+
+	ks := PrimedKeysort(specimen, -1)
+	sort.Sort(ks)
+
+	primingError, ok := ks.Errors().(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError, got %v", ks.Errors())
+	}
+	if primingError.ErrorAt(1) == nil || len(primingError.Errors) != 1 {
+		t.Errorf("Expected a normalization error at index 1, got %v", primingError.Errors)
+	}
+}
+
+// PanickyNumbers is MixedNumbers whose Normalize panics on a string.
+type PanickyNumbers struct{ MixedNumbers }
+
+func (s PanickyNumbers) Normalize(key interface{}) (interface{}, error) {
+	if _, ok := key.(string); ok {
+		panic("cannot normalize a string")
+	}
+	return s.MixedNumbers.Normalize(key)
+}
+
+func TestKeyNormalizerPanics(t *testing.T) {
+	specimen := PanickyNumbers{MixedNumbers{3, 1.5, "two", 0.5}}
+
+	err := Keysort(specimen).Sort()
+
+	primingError, ok := err.(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError, got %v", err)
+	}
+	for _, err := range primingError.Errors {
+		if _, ok := err.(KeyPanicError); !ok {
+			t.Errorf("Expected a KeyPanicError, got %v", err)
+		}
+	}
+	if len(primingError.Errors) != 1 {
+		t.Errorf("Expected exactly 1 error, got %d", len(primingError.Errors))
+	}
+}
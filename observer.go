@@ -0,0 +1,46 @@
+package keysort
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// An Observer is told about the progress of priming, for example to record
+// metrics. Calls to an Observer are serialized, so it need not be safe for
+// concurrent use.
+type Observer interface {
+	// OnPrimeStart is called once, before any key is computed, with the
+	// number of keys to compute.
+	OnPrimeStart(total int)
+	// OnKeyDone is called after the key at index i has been computed,
+	// with how long it took and the error it returned, if any.
+	OnKeyDone(i int, dur time.Duration, err error)
+	// OnPrimeEnd is called once, after every key has been computed.
+	OnPrimeEnd()
+}
+
+// PrimedKeysortObserved is like PrimedKeysort, but reports on priming to obs.
+// Keys computed later, while sorting, are not reported.
+func PrimedKeysortObserved(wrapped Interface, parallelism int, obs Observer) *keySortable {
+	ks := Keysort(wrapped)
+	// lock serializes the calls to obs.
+	var lock sync.Mutex
+
+	obs.OnPrimeStart(ks.Len())
+	ks.memoizeWith(context.Background(), parallelism, ks.allIndexes, func(i int) {
+		start := time.Now()
+		ks.Key(i)
+		dur := time.Since(start)
+
+		ks.Lock()
+		err := ks.errors[i]
+		ks.Unlock()
+
+		lock.Lock()
+		defer lock.Unlock()
+		obs.OnKeyDone(i, dur, err)
+	})
+	obs.OnPrimeEnd()
+	return ks
+}
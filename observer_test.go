@@ -0,0 +1,66 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+// RecordingObserver records the callbacks it receives, in order.
+type RecordingObserver struct {
+	events []string
+	total  int
+	done   map[int]error
+}
+
+func (o *RecordingObserver) OnPrimeStart(total int) {
+	o.events = append(o.events, "start")
+	o.total = total
+	o.done = map[int]error{}
+}
+
+func (o *RecordingObserver) OnKeyDone(i int, dur time.Duration, err error) {
+	o.events = append(o.events, "key")
+	if _, ok := o.done[i]; ok {
+		panic("OnKeyDone called twice for one index")
+	}
+	o.done[i] = err
+}
+
+func (o *RecordingObserver) OnPrimeEnd() {
+	o.events = append(o.events, "end")
+}
+
+func TestPrimedKeysortObserved(t *testing.T) {
+	specimen := ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)}
+	obs := &RecordingObserver{}
+
+	ks := PrimedKeysortObserved(specimen, 4, obs)
+
+	if len(obs.events) != SPECIMEN_SIZE+2 {
+		t.Fatalf("Expected %d callbacks, got %d", SPECIMEN_SIZE+2, len(obs.events))
+	}
+	if obs.events[0] != "start" || obs.events[len(obs.events)-1] != "end" {
+		t.Errorf("Expected start first and end last, got %v", obs.events)
+	}
+	for _, event := range obs.events[1 : len(obs.events)-1] {
+		if event != "key" {
+			t.Errorf("Expected only key callbacks between start and end, got %v", obs.events)
+		}
+	}
+	if obs.total != SPECIMEN_SIZE {
+		t.Errorf("Expected total %d, got %d", SPECIMEN_SIZE, obs.total)
+	}
+	if len(obs.done) != SPECIMEN_SIZE {
+		t.Errorf("Expected %d distinct keys, got %d", SPECIMEN_SIZE, len(obs.done))
+	}
+	if obs.done[1] == nil {
+		t.Errorf("Expected the error at index 1 to be reported")
+	}
+
+	// Keys computed while sorting are not reported.
+	sort.Sort(ks)
+	if len(obs.events) != SPECIMEN_SIZE+2 {
+		t.Errorf("Expected no more callbacks after priming, got %d", len(obs.events))
+	}
+}
@@ -0,0 +1,63 @@
+package keysort
+
+import "context"
+
+// Options configures SortWith. The zero value sorts like Keysort, computing
+// keys as the sort needs them.
+type Options struct {
+	// Parallelism, if not zero, primes every key before sorting, using this
+	// many goroutines as for PrimedKeysort. If it is negative,
+	// runtime.GOMAXPROCS goroutines are used.
+	Parallelism int
+	// Stable sorts with sort.Stable, so that elements with equal keys keep
+	// their original relative order.
+	Stable bool
+	// Descending sorts in descending order of key, as for DescendingBy. Combined
+	// with Stable, elements with equal keys still keep their original
+	// relative order: only the order of unequal keys is flipped.
+	Descending bool
+	// FailFast primes every key before sorting, as for
+	// PrimedKeysortFailFast, and does not sort at all if any key fails. If
+	// Parallelism is zero, runtime.GOMAXPROCS goroutines are used.
+	FailFast bool
+	// Context, if not nil, stops priming or sorting once it is cancelled, in
+	// which case its error is returned, as for KeysortContext.
+	Context context.Context
+}
+
+// SortWith sorts wrapped, combining the behaviours described by opts. It
+// returns ctx.Err() if opts.Context was cancelled, and otherwise the result of
+// Errors(). The wrapped container is left unsorted if priming failed under
+// FailFast or was cancelled, and partly sorted if sorting was cancelled.
+func SortWith(wrapped Interface, opts Options) error {
+	ks := KeysortContext(opts.Context, wrapped)
+	ks.descending = opts.Descending
+
+	parent := opts.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	if opts.Parallelism != 0 || opts.FailFast {
+		ctx, cancel := context.WithCancel(parent)
+		defer cancel()
+		ks.memoizeWith(ctx, opts.Parallelism, ks.allIndexes, func(i int) {
+			ks.Key(i)
+			if opts.FailFast && ks.hasErrors() {
+				cancel()
+			}
+		})
+	}
+	if err := parent.Err(); err != nil {
+		return err
+	}
+	if opts.FailFast {
+		if err := ks.Errors(); err != nil {
+			return err
+		}
+	}
+
+	if opts.Stable {
+		return ks.Stable()
+	}
+	return ks.Sort()
+}
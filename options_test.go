@@ -0,0 +1,101 @@
+package keysort
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSortWithZero(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	if err := SortWith(specimen, Options{}); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !sort.IsSorted(specimen) {
+		t.Errorf("SortWith failed for ByIntKey")
+	}
+}
+
+func TestSortWithStableDescending(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	for i := range specimen {
+		// Use NotKey to remember the input order, and force duplicate keys.
+		specimen[i].NotKey = i
+		specimen[i].IntKey = rand.Intn(3)
+	}
+
+	err := SortWith(ByIntKey{specimen}, Options{Parallelism: 4, Stable: true, Descending: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for i := 1; i < len(specimen); i++ {
+		prev, cur := specimen[i-1], specimen[i]
+		if prev.IntKey < cur.IntKey {
+			t.Errorf("Not in descending order at %d", i)
+		}
+		if prev.IntKey == cur.IntKey && prev.NotKey > cur.NotKey {
+			t.Errorf("Equal keys not in original order at %d", i)
+		}
+	}
+}
+
+func TestSortWithDescendingNormalizer(t *testing.T) {
+	specimen := MixedNumbers{3, 1.5, 2, 0.5, 4.25, 1}
+
+	if err := SortWith(specimen, Options{Descending: true}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := MixedNumbers{4.25, 3, 2, 1.5, 1, 0.5}
+	for i := range expected {
+		if specimen[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, specimen)
+			break
+		}
+	}
+}
+
+func TestSortWithDescendingLessValErr(t *testing.T) {
+	specimen := ByMixedKeys{GenSpecimen(SPECIMEN_SIZE)}
+	specimen.SpecimenSliceSorter[3].NotKey = -1
+
+	err := SortWith(specimen, Options{Descending: true})
+
+	primingError, ok := err.(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError, got %v", err)
+	}
+	for _, err := range primingError.Errors {
+		if _, ok := err.(CompareError); !ok {
+			t.Errorf("Expected a CompareError, got %v", err)
+		}
+	}
+}
+
+func TestSortWithFailFast(t *testing.T) {
+	specimen := ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)}
+	original := append(SpecimenSliceSorter{}, specimen.SpecimenSliceSorter...)
+
+	if err := SortWith(specimen, Options{FailFast: true}); err == nil {
+		t.Errorf("Errors were expected.")
+	}
+	for i := range original {
+		if specimen.SpecimenSliceSorter[i] != original[i] {
+			t.Errorf("Expected the container to be left unsorted")
+			break
+		}
+	}
+}
+
+func TestSortWithContext(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := SortWith(specimen, Options{Parallelism: 2, Context: ctx}); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package keysort
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// OrderBuilder builds a keySortable that sorts by several columns in turn, like
+// a SQL ORDER BY clause. It is created by OrderBy.
+type OrderBuilder struct {
+	wrapped KeyProvider
+	columns []orderColumn
+}
+
+// orderColumn is one column of an ORDER BY.
+type orderColumn struct {
+	key  func(record interface{}) (interface{}, error)
+	desc bool
+}
+
+// OrderBy starts building a sort of wrapped by columns. The key of each element
+// of wrapped is taken as its record, from which each column's key is computed.
+// For instance, to sort employees "ORDER BY dept ASC, salary DESC":
+//
+//	OrderBy(employees).Asc(dept).Desc(salary).Build().Sort()
+func OrderBy(wrapped KeyProvider) *OrderBuilder {
+	return &OrderBuilder{wrapped: wrapped}
+}
+
+// Asc adds a column that sorts in ascending order of the key that key computes
+// from each record.
+func (b *OrderBuilder) Asc(key func(record interface{}) (interface{}, error)) *OrderBuilder {
+	b.columns = append(b.columns, orderColumn{key, false})
+	return b
+}
+
+// Desc adds a column that sorts in descending order of the key that key
+// computes from each record.
+func (b *OrderBuilder) Desc(key func(record interface{}) (interface{}, error)) *OrderBuilder {
+	b.columns = append(b.columns, orderColumn{key, true})
+	return b
+}
+
+// Build returns a keySortable that sorts by each column in the order they were
+// added, consulting later columns only to break ties between earlier ones.
+//
+// Column keys must be integers, floats, strings or time.Time, and are compared
+// with <, or with time.Time.Before. Each record, and each column key of each
+// record, is memoized independently, and a column key is only computed once
+// it is needed to break a tie. A column key that fails, or that cannot be
+// compared, is recorded as a CompareError. Build panics if no columns were
+// added.
+func (b *OrderBuilder) Build() *keySortable {
+	if len(b.columns) == 0 {
+		panic("keysort: OrderBy needs at least one column")
+	}
+	return Keysort(ordered{b.wrapped, append([]orderColumn{}, b.columns...)})
+}
+
+// ordered is the Interface built by an OrderBuilder. Its keys are *orderRows.
+type ordered struct {
+	KeyProvider
+	columns []orderColumn
+}
+
+// orderRow is a record, and the column keys computed from it so far.
+type orderRow struct {
+	record  interface{}
+	columns []memoCell
+	errs    []error
+	sync.Mutex
+}
+
+// Key returns a row for the record of the element at i.
+func (o ordered) Key(i int) (interface{}, error) {
+	record, err := o.KeyProvider.Key(i)
+	if err != nil {
+		return nil, err
+	}
+	return &orderRow{record: record, columns: make([]memoCell, len(o.columns)), errs: make([]error, len(o.columns))}, nil
+}
+
+// column returns the key of column n of row, computing it if need be.
+func (o ordered) column(row *orderRow, n int) (interface{}, error) {
+	row.Lock()
+	defer row.Unlock()
+	if !row.columns[n].computed {
+		value, err := o.columns[n].key(row.record)
+		row.columns[n] = memoCell{computed: true, value: value}
+		row.errs[n] = err
+	}
+	return row.columns[n].value, row.errs[n]
+}
+
+// LessValE compares two rows column by column.
+func (o ordered) LessValE(a, b interface{}) (bool, error) {
+	aRow, bRow := a.(*orderRow), b.(*orderRow)
+	for n, column := range o.columns {
+		aKey, err := o.column(aRow, n)
+		if err != nil {
+			return false, err
+		}
+		bKey, err := o.column(bRow, n)
+		if err != nil {
+			return false, err
+		}
+		less, err := columnLess(aKey, bKey)
+		if err != nil {
+			return false, err
+		}
+		if column.desc {
+			aKey, bKey = bKey, aKey
+		}
+		if less(aKey, bKey) {
+			return true, nil
+		}
+		if less(bKey, aKey) {
+			return false, nil
+		}
+	}
+	return false, nil
+}
+
+// LessVal is like LessValE, reporting false if the rows cannot be compared.
+func (o ordered) LessVal(a, b interface{}) bool {
+	less, _ := o.LessValE(a, b)
+	return less
+}
+
+// columnLess returns the Comparator for two column keys, which must have the
+// same type.
+func columnLess(a, b interface{}) (Comparator, error) {
+	aType, bType := reflect.TypeOf(a), reflect.TypeOf(b)
+	if aType == nil || aType != bType {
+		return nil, fmt.Errorf("keysort: cannot compare column keys %#v and %#v", a, b)
+	}
+	return defaultLess(aType)
+}
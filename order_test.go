@@ -0,0 +1,94 @@
+package keysort
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// Employee is a row of a table to be ordered.
+type Employee struct {
+	Dept   string
+	Salary int
+}
+
+// Employees sorts by its own records.
+type Employees []Employee
+
+func (s Employees) Key(i int) (interface{}, error) { return s[i], nil }
+func (s Employees) Swap(i, j int)                  { s[i], s[j] = s[j], s[i] }
+func (s Employees) Len() int                       { return len(s) }
+
+func dept(record interface{}) (interface{}, error) {
+	return record.(Employee).Dept, nil
+}
+
+func salary(record interface{}) (interface{}, error) {
+	return record.(Employee).Salary, nil
+}
+
+func genEmployees() Employees {
+	employees := Employees{}
+	for i, specimen := range GenSpecimen(SPECIMEN_SIZE) {
+		employees = append(employees, Employee{[]string{"eng", "ops", "sales"}[i%3], specimen.IntKey})
+	}
+	return employees
+}
+
+func TestOrderBy(t *testing.T) {
+	employees := genEmployees()
+	expected := append(Employees{}, employees...)
+	// SELECT * FROM employees ORDER BY dept ASC, salary DESC
+	sort.Slice(expected, func(i, j int) bool {
+		if expected[i].Dept != expected[j].Dept {
+			return expected[i].Dept < expected[j].Dept
+		}
+		return expected[i].Salary > expected[j].Salary
+	})
+
+	if err := OrderBy(employees).Asc(dept).Desc(salary).Build().Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for i := range expected {
+		if employees[i].Dept != expected[i].Dept || employees[i].Salary != expected[i].Salary {
+			t.Errorf("Expected %v at %d, got %v", expected[i], i, employees[i])
+		}
+	}
+}
+
+func TestOrderByColumnsMemoized(t *testing.T) {
+	employees := genEmployees()
+	deptCalls, salaryCalls := 0, 0
+
+	err := OrderBy(employees).
+		Asc(func(record interface{}) (interface{}, error) {
+			deptCalls++
+			return dept(record)
+		}).
+		Asc(func(record interface{}) (interface{}, error) {
+			salaryCalls++
+			return salary(record)
+		}).
+		Build().Sort()
+
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if deptCalls != SPECIMEN_SIZE || salaryCalls > SPECIMEN_SIZE {
+		t.Errorf("Expected each column key computed at most once per record, got %d and %d",
+			deptCalls, salaryCalls)
+	}
+}
+
+func TestOrderByErrors(t *testing.T) {
+	employees := genEmployees()
+
+	err := OrderBy(employees).Asc(dept).Desc(func(record interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("Blah")
+	}).Build().Sort()
+
+	if err == nil {
+		t.Errorf("Errors were expected.")
+	}
+}
@@ -0,0 +1,133 @@
+package keysort
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// KeyPanicError is recorded against an index whose Key() panicked. Value is the
+// value passed to panic, and Stack is the stack trace of the panicking
+// goroutine.
+type KeyPanicError struct {
+	Index int
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns a string representation of this error.
+func (e KeyPanicError) Error() string {
+	return fmt.Sprintf("Key(%d) panicked: %v", e.Index, e.Value)
+}
+
+// safeKey calls wrapped.Key() on the element currently at index i, whose
+// original index is originalIndex, converting a panic into a KeyPanicError.
+func (ks *keySortable) safeKey(i, originalIndex int) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			value, err = nil, KeyPanicError{Index: originalIndex, Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return ks.key(i)
+}
+
+// IndexPanicError is returned by Errors() if the index generator used while
+// priming panicked. Value is the value passed to panic, and Stack is the stack
+// trace of the panicking goroutine. Keys for the indices generated before the
+// panic are still memoized.
+type IndexPanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns a string representation of this error.
+func (e IndexPanicError) Error() string {
+	return fmt.Sprintf("index generator panicked: %v", e.Value)
+}
+
+// safeGenIndexes runs genIndexes, closing generated and recording an
+// IndexPanicError if it panics, so that the goroutines waiting on generated
+// always finish.
+func (ks *keySortable) safeGenIndexes(genIndexes func(chan<- int), generated chan int) {
+	defer func() {
+		if r := recover(); r != nil {
+			ks.Lock()
+			if ks.genErr == nil {
+				ks.genErr = IndexPanicError{Value: r, Stack: debug.Stack()}
+			}
+			ks.Unlock()
+			// genIndexes may have closed generated before panicking.
+			defer func() { recover() }()
+			close(generated)
+		}
+	}()
+	genIndexes(generated)
+}
+
+// LessPanicError is recorded, wrapped in a CompareError, when LessVal()
+// panicked in a keySortable created by KeysortRecoverLess.
+type LessPanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns a string representation of this error.
+func (e LessPanicError) Error() string {
+	return fmt.Sprintf("LessVal panicked: %v", e.Value)
+}
+
+// KeysortRecoverLess is like Keysort, but recovers from any panic in
+// wrapped.LessVal(), for instance from a bad type assertion on a malformed
+// key. The panic is recorded as a CompareError against the first of the two
+// elements, and the pair is treated as equal, so that the sort runs to
+// completion and the error is returned by Errors().
+func KeysortRecoverLess(wrapped Interface) *keySortable {
+	ks := Keysort(wrapped)
+	ks.recoverLess = true
+	return ks
+}
+
+// lessVal calls wrapped.LessVal(a, b), or wrapped.LessValE(a, b) if it
+// implements LessValErr, where a and b are the keys of the elements currently at
+// i and j; their order is flipped first if ks sorts in descending order. ok is
+// false if the comparison failed, or panicked and ks recovers from such panics,
+// in which case the failure has been recorded.
+func (ks *keySortable) lessVal(i, j int, a, b interface{}) (less, ok bool) {
+	if ks.descending {
+		a, b = b, a
+	}
+	if ks.recoverLess {
+		defer ks.recoverCompare(i, j, &ok)
+	}
+	if lessValErr, isErr := optional[LessValErr](ks.wrapped); isErr {
+		less, err := lessValErr.LessValE(a, b)
+		if err != nil {
+			ks.recordError(ks.swaps[i], CompareError{ks.swaps[i], ks.swaps[j], err})
+			return false, false
+		}
+		return less, true
+	}
+	return ks.wrapped.LessVal(a, b), true
+}
+
+// cmpVal is like lessVal, but compares a and b three ways with a single call to
+// cmp.CmpVal(a, b).
+func (ks *keySortable) cmpVal(i, j int, cmp CmpValer, a, b interface{}) (result int, ok bool) {
+	if ks.descending {
+		a, b = b, a
+	}
+	if ks.recoverLess {
+		defer ks.recoverCompare(i, j, &ok)
+	}
+	return cmp.CmpVal(a, b), true
+}
+
+// recoverCompare is deferred by a comparison of the keys of the elements
+// currently at i and j. It records a panic in the comparison as a CompareError,
+// and sets *ok to false.
+func (ks *keySortable) recoverCompare(i, j int, ok *bool) {
+	if r := recover(); r != nil {
+		err := LessPanicError{Value: r, Stack: debug.Stack()}
+		ks.recordError(ks.swaps[i], CompareError{ks.swaps[i], ks.swaps[j], err})
+		*ok = false
+	}
+}
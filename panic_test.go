@@ -0,0 +1,129 @@
+package keysort
+
+import (
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestKeyPanicRecovered(t *testing.T) {
+	specimen := ByIntKeyPanics{GenSpecimen(SPECIMEN_SIZE), 3}
+
+	ks := PrimedKeysort(specimen, -1)
+
+	err := ks.Errors()
+	if err == nil {
+		t.Fatalf("Errors were expected.")
+	}
+	errors := err.(PrimingError).Errors
+	if len(errors) != 1 {
+		t.Errorf("Expected exactly 1 error, got %d.", len(errors))
+	}
+	if panicErr, ok := errors[3].(KeyPanicError); !ok {
+		t.Errorf("Expected a KeyPanicError for index 3, got %v", errors[3])
+	} else if panicErr.Index != 3 || panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+		t.Errorf("KeyPanicError is missing information: %+v", panicErr)
+	}
+	if memoized := countMemoized(ks); memoized != SPECIMEN_SIZE-1 {
+		t.Errorf("Expected all %d keys but the panicking one memoized, got %d", SPECIMEN_SIZE-1, memoized)
+	}
+}
+
+func TestKeyPanicRecoveredWithTimeout(t *testing.T) {
+	specimen := ByIntKeyPanics{GenSpecimen(SPECIMEN_SIZE), 3}
+
+	ks := PrimedKeysortTimeout(specimen, -1, time.Second)
+
+	if _, ok := ks.Errors().(PrimingError).Errors[3].(KeyPanicError); !ok {
+		t.Errorf("Expected a KeyPanicError for index 3")
+	}
+}
+
+// ByIntKeyPanics panics when asked for the key at index panicAt.
+type ByIntKeyPanics struct {
+	SpecimenSliceSorter
+	panicAt int
+}
+
+func (s ByIntKeyPanics) LessVal(i, j interface{}) bool {
+	return i.(int) < j.(int)
+}
+
+func (s ByIntKeyPanics) Key(i int) (interface{}, error) {
+	if i == s.panicAt {
+		panic("boom")
+	}
+	return s.At(i).IntKey, nil
+}
+
+func TestIndexPanicRecovered(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ks := Keysort(ByIntKey{GenSpecimen(SPECIMEN_SIZE)})
+
+	ks.memoize(4, func(iChan chan<- int) {
+		iChan <- 0
+		iChan <- 1
+		panic("bad index source")
+	})
+
+	if _, ok := ks.Errors().(IndexPanicError); !ok {
+		t.Errorf("Expected an IndexPanicError, got %v", ks.Errors())
+	}
+	if ks.PrimedCount() != 2 {
+		t.Errorf("Expected the keys generated before the panic to be memoized, got %d", ks.PrimedCount())
+	}
+
+	// The last goroutines may still be exiting after memoize returns.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Expected no leaked goroutines, had %d before and %d after", before, after)
+	}
+}
+
+// ByIntKeyMalformed has a key that is not an int at the element whose NotKey
+// is malformedAt, so that its LessVal panics.
+type ByIntKeyMalformed struct {
+	ByIntKey
+	malformedAt int
+}
+
+func (s ByIntKeyMalformed) Key(i int) (interface{}, error) {
+	if s.At(i).NotKey == s.malformedAt {
+		return "malformed", nil
+	}
+	return s.ByIntKey.Key(i)
+}
+
+func TestKeysortRecoverLess(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	for i := range specimen {
+		specimen[i].NotKey = i
+	}
+
+	ks := KeysortRecoverLess(ByIntKeyMalformed{ByIntKey{specimen}, 3})
+	sort.Sort(ks)
+
+	primingError, ok := ks.Errors().(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError, got %v", ks.Errors())
+	}
+	var lessPanic LessPanicError
+	for _, err := range primingError.Errors {
+		compareError, ok := err.(CompareError)
+		if !ok {
+			t.Errorf("Expected a CompareError, got %v", err)
+			continue
+		}
+		if compareError.I != 3 && compareError.J != 3 {
+			t.Errorf("Expected the malformed element in %v", compareError)
+		}
+		lessPanic, _ = compareError.Err.(LessPanicError)
+	}
+	if lessPanic.Value == nil {
+		t.Errorf("Expected a LessPanicError to be recorded")
+	}
+}
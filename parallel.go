@@ -0,0 +1,254 @@
+package keysort
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelSortThreshold is the length below which ParallelSort falls back to
+// sorting sequentially.
+const parallelSortThreshold = 1 << 12
+
+// ParallelSort primes the keys of wrapped using parallelism goroutines, and
+// then sorts it with a parallel merge sort over the memoized keys: the
+// container is split into parallelism chunks which are sorted concurrently,
+// and then merged. The resulting permutation is applied to wrapped with Swap.
+// If parallelism is less than one, runtime.GOMAXPROCS goroutines are used.
+//
+// The sort is stable, so it produces the same order as sort.Stable on a
+// Keysort. Small inputs, or any input if GOMAXPROCS is 1, are sorted
+// sequentially once primed, since splitting them up would only add work. Keys
+// are compared as by a keySortable, so LessValErr is respected. If any key or
+// comparison fails, the errors are returned and wrapped is left unsorted.
+//
+// If a goroutine panics while sorting or merging, the other goroutines give up
+// as soon as they next compare keys, and once all of them have returned, a
+// ParallelError describing the panics is returned. wrapped is left unsorted.
+func ParallelSort(wrapped Interface, parallelism int) error {
+	return parallelSort(wrapped, parallelism, runtime.GOMAXPROCS(-1) > 1)
+}
+
+// parallelSort is ParallelSort, sorting sequentially once primed unless
+// concurrent is set.
+func parallelSort(wrapped Interface, parallelism int, concurrent bool) error {
+	if parallelism < 1 {
+		parallelism = runtime.GOMAXPROCS(-1)
+	}
+	ks := Keysort(wrapped)
+	defer ks.acquire()()
+	ks.memoize(parallelism, ks.allIndexes)
+	if err := ks.Errors(); err != nil {
+		return err
+	}
+
+	n := ks.Len()
+	if !concurrent || parallelism == 1 || n < parallelSortThreshold {
+		sort.Stable(ks)
+		return ks.Errors()
+	}
+
+	keys := make([]interface{}, n)
+	for i := range keys {
+		keys[i] = ks.Key(i)
+	}
+
+	// Sort each chunk of indices concurrently.
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	chunkSize := (n + parallelism - 1) / parallelism
+	runs := [][]int{}
+	for lo := 0; lo < n; lo += chunkSize {
+		runs = append(runs, order[lo:min(lo+chunkSize, n)])
+	}
+	workers := &parallelWorkers{}
+	// Nothing has been swapped yet, so positions are original indices.
+	less := func(i, j int) bool {
+		if workers.failed() {
+			// Finish quickly, since the result will be thrown away.
+			return false
+		}
+		less, _ := ks.lessVal(i, j, keys[i], keys[j])
+		return less
+	}
+	for worker, run := range runs {
+		run := run
+		workers.start("sort", worker, func() {
+			sort.Stable(runSorter{run, less})
+		})
+	}
+	if err := workers.wait(); err != nil {
+		return err
+	}
+
+	// Merge pairs of runs concurrently until only one remains, alternating
+	// between order and scratch as the buffer to merge into.
+	src, dst := order, make([]int, n)
+	for len(runs) > 1 {
+		merged := make([][]int, 0, (len(runs)+1)/2)
+		offset := 0
+		for r := 0; r < len(runs); r += 2 {
+			if r+1 == len(runs) {
+				out := dst[offset : offset+len(runs[r])]
+				copy(out, runs[r])
+				merged = append(merged, out)
+				break
+			}
+			a, b, out := runs[r], runs[r+1], dst[offset:offset+len(runs[r])+len(runs[r+1])]
+			offset += len(out)
+			workers.start("merge", r/2, func() {
+				mergeRuns(a, b, out, less)
+			})
+			merged = append(merged, out)
+		}
+		if err := workers.wait(); err != nil {
+			return err
+		}
+		runs = merged
+		src, dst = dst, src
+	}
+
+	if err := ks.Errors(); err != nil {
+		return err
+	}
+	applyPermutation(ks.Swap, runs[0])
+	return ks.Errors()
+}
+
+// WorkerPanic describes a panic in one of the goroutines of ParallelSort. Phase
+// is "sort" or "merge", and Worker numbers the goroutines of that phase.
+type WorkerPanic struct {
+	Phase  string
+	Worker int
+	Value  interface{}
+	Stack  []byte
+}
+
+// ParallelError is returned by ParallelSort if any of its goroutines panicked.
+type ParallelError struct {
+	Panics []WorkerPanic
+}
+
+// Error returns a string representation of this error.
+func (e ParallelError) Error() string {
+	var b strings.Builder
+	b.WriteString("ParallelSort failed.\n")
+	for _, p := range e.Panics {
+		fmt.Fprintf(&b, "\t%s worker %d panicked: %v\n", p.Phase, p.Worker, p.Value)
+	}
+	return b.String()
+}
+
+// parallelWorkers runs the goroutines of one phase of ParallelSort, recovering
+// from their panics.
+type parallelWorkers struct {
+	wg sync.WaitGroup
+	// panicked is set once any goroutine has panicked. It is accessed
+	// atomically.
+	panicked int32
+	lock     sync.Mutex
+	panics   []WorkerPanic
+}
+
+// start runs task in a new goroutine.
+func (w *parallelWorkers) start(phase string, worker int, task func()) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				atomic.StoreInt32(&w.panicked, 1)
+				w.lock.Lock()
+				defer w.lock.Unlock()
+				w.panics = append(w.panics, WorkerPanic{phase, worker, r, debug.Stack()})
+			}
+		}()
+		task()
+	}()
+}
+
+// failed reports whether any goroutine has panicked.
+func (w *parallelWorkers) failed() bool {
+	return atomic.LoadInt32(&w.panicked) != 0
+}
+
+// wait waits for every goroutine that has been started to return, and returns
+// a ParallelError if any of them panicked.
+func (w *parallelWorkers) wait() error {
+	w.wg.Wait()
+	if !w.failed() {
+		return nil
+	}
+	return ParallelError{w.panics}
+}
+
+// indexSorter sorts a slice of indices by the keys they refer to.
+type indexSorter struct {
+	indices []int
+	keys    []interface{}
+	less    func(a, b interface{}) bool
+}
+
+func (s indexSorter) Len() int { return len(s.indices) }
+func (s indexSorter) Swap(i, j int) {
+	s.indices[i], s.indices[j] = s.indices[j], s.indices[i]
+}
+func (s indexSorter) Less(i, j int) bool {
+	return s.less(s.keys[s.indices[i]], s.keys[s.indices[j]])
+}
+
+// runSorter sorts a run of indices, comparing the keys at those indices with
+// less.
+type runSorter struct {
+	indices []int
+	less    func(i, j int) bool
+}
+
+func (s runSorter) Len() int           { return len(s.indices) }
+func (s runSorter) Swap(i, j int)      { s.indices[i], s.indices[j] = s.indices[j], s.indices[i] }
+func (s runSorter) Less(i, j int) bool { return s.less(s.indices[i], s.indices[j]) }
+
+// mergeRuns merges the sorted runs of indices a and b into out, preferring a on
+// ties so that the merge is stable.
+func mergeRuns(a, b, out []int, less func(i, j int) bool) {
+	i, j := 0, 0
+	for k := range out {
+		if j == len(b) || (i < len(a) && !less(b[j], a[i])) {
+			out[k] = a[i]
+			i++
+		} else {
+			out[k] = b[j]
+			j++
+		}
+	}
+}
+
+// applyPermutation rearranges a container with swap, so that afterwards the
+// element at each position newPos is the one that was at perm[newPos]
+// beforehand. It performs at most len(perm) swaps.
+func applyPermutation(swap func(i, j int), perm []int) {
+	// where maps an element's position before we started to its current
+	// position, and at is the inverse of where.
+	where := make([]int, len(perm))
+	at := make([]int, len(perm))
+	for i := range perm {
+		where[i], at[i] = i, i
+	}
+
+	for newPos, oldPos := range perm {
+		current := where[oldPos]
+		if current == newPos {
+			continue
+		}
+		swap(newPos, current)
+		displaced := at[newPos]
+		at[newPos], at[current] = oldPos, displaced
+		where[oldPos], where[displaced] = newPos, current
+	}
+}
@@ -0,0 +1,121 @@
+package keysort
+
+import (
+	"math/rand"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParallelSortMatchesStable(t *testing.T) {
+	for _, size := range []int{SPECIMEN_SIZE, 3*parallelSortThreshold + 17} {
+		specimen := GenSpecimen(size)
+		for i := range specimen {
+			specimen[i].NotKey = i
+			specimen[i].IntKey = rand.Intn(size / 4)
+		}
+		expected := ByIntKey{append(SpecimenSliceSorter{}, specimen...)}
+		actual := ByIntKey{specimen}
+
+		sort.Stable(Keysort(expected))
+		if err := parallelSort(actual, 4, true); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		for i := range specimen {
+			if expected.At(i) != actual.At(i) {
+				t.Fatalf("Size %d: ParallelSort differs from sort.Stable at %d", size, i)
+			}
+		}
+	}
+}
+
+func TestParallelSortErrors(t *testing.T) {
+	specimen := ByIntKeyHalfErrors{GenSpecimen(SPECIMEN_SIZE)}
+
+	if err := ParallelSort(specimen, 4); err == nil {
+		t.Errorf("Errors were expected.")
+	}
+}
+
+func TestParallelSortLessValErr(t *testing.T) {
+	size := 3*parallelSortThreshold + 17
+	specimen := ByMixedKeys{GenSpecimen(size)}
+	for i := range specimen.SpecimenSliceSorter {
+		specimen.SpecimenSliceSorter[i].NotKey = i
+	}
+	specimen.SpecimenSliceSorter[size/2].NotKey = -1
+
+	primingError, ok := parallelSort(specimen, 4, true).(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError")
+	}
+	for _, err := range primingError.Errors {
+		if _, ok := err.(CompareError); !ok {
+			t.Errorf("Expected a CompareError, got %v", err)
+		}
+	}
+	if specimen.SpecimenSliceSorter[size/2].NotKey != -1 {
+		t.Errorf("Expected the container to be left unsorted")
+	}
+}
+
+func TestApplyPermutation(t *testing.T) {
+	values := []int{10, 11, 12, 13, 14, 15}
+	perm := []int{3, 5, 0, 1, 4, 2}
+
+	applyPermutation(func(i, j int) { values[i], values[j] = values[j], values[i] }, perm)
+
+	for newPos, oldPos := range perm {
+		if values[newPos] != 10+oldPos {
+			t.Errorf("Expected %d at %d, got %d", 10+oldPos, newPos, values[newPos])
+		}
+	}
+}
+
+// ByIntKeyLessPanics panics when comparing a negative key.
+type ByIntKeyLessPanics struct{ ByIntKey }
+
+func (s ByIntKeyLessPanics) LessVal(i, j interface{}) bool {
+	if i.(int) < 0 || j.(int) < 0 {
+		panic("negative key")
+	}
+	return i.(int) < j.(int)
+}
+
+func TestParallelSortPanic(t *testing.T) {
+	size := 3*parallelSortThreshold + 17
+	specimen := ByIntKeyLessPanics{ByIntKey{GenSpecimen(size)}}
+	specimen.SpecimenSliceSorter[size/2].IntKey = -1
+	before := runtime.NumGoroutine()
+
+	err := parallelSort(specimen, 4, true)
+
+	parallelError, ok := err.(ParallelError)
+	if !ok {
+		t.Fatalf("Expected a ParallelError, got %v", err)
+	}
+	if len(parallelError.Panics) != 1 {
+		t.Fatalf("Expected a single panic, got %v", parallelError.Panics)
+	}
+	if p := parallelError.Panics[0]; p.Phase != "sort" || p.Worker != 1 || p.Value != "negative key" {
+		t.Errorf("Expected sort worker 1 to panic with negative key, got %s", parallelError)
+	}
+	if !strings.Contains(err.Error(), "sort worker 1 panicked: negative key") {
+		t.Errorf("Expected a descriptive error, got %q", err)
+	}
+	// The workers that did not panic may still be exiting after ParallelSort
+	// returns.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Expected no goroutines left running, went from %d to %d", before, after)
+	}
+	if specimen.SpecimenSliceSorter[size/2].IntKey != -1 {
+		t.Errorf("Expected the container to be left unsorted")
+	}
+}
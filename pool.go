@@ -0,0 +1,74 @@
+package keysort
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// workerPool is a fixed set of goroutines that run submitted tasks.
+type workerPool struct {
+	tasks chan func()
+	wg    sync.WaitGroup
+	// started counts the goroutines this pool has started. It is accessed
+	// atomically.
+	started int64
+}
+
+// newWorkerPool starts a workerPool of size goroutines. If size is less than
+// one, runtime.GOMAXPROCS goroutines are used.
+func newWorkerPool(size int) *workerPool {
+	if size < 1 {
+		size = runtime.GOMAXPROCS(-1)
+	}
+	pool := &workerPool{tasks: make(chan func())}
+	pool.wg.Add(size)
+	for i := 0; i < size; i++ {
+		atomic.AddInt64(&pool.started, 1)
+		go func() {
+			defer pool.wg.Done()
+			for task := range pool.tasks {
+				task()
+			}
+		}()
+	}
+	return pool
+}
+
+// submit runs task on the next free goroutine, blocking until there is one.
+func (p *workerPool) submit(task func()) {
+	p.tasks <- task
+}
+
+// close stops the pool's goroutines once they finish their current tasks.
+func (p *workerPool) close() {
+	close(p.tasks)
+	p.wg.Wait()
+}
+
+// PrimedKeysortPooled is like PrimedKeysort, but memoizes keys on a pool of
+// parallelism goroutines that the keySortable keeps, so that later calls to
+// RetryFailed reuse them rather than starting new goroutines each time.
+// Close must be called to stop the pool once it is no longer needed.
+func PrimedKeysortPooled(wrapped Interface, parallelism int) *keySortable {
+	ks := Keysort(wrapped)
+	ks.pool = newWorkerPool(parallelism)
+	ks.memoize(parallelism, ks.allIndexes)
+	return ks
+}
+
+// Close stops the worker pool of a keySortable created by
+// PrimedKeysortPooled, or the priming of one created by
+// BackgroundPrimedKeysort. Any later memoization starts fresh goroutines, as
+// for PrimedKeysort. It is safe to call Close on any keySortable, more than
+// once.
+func (ks *keySortable) Close() {
+	if ks.stopBackground != nil {
+		ks.stopBackground()
+		ks.stopBackground = nil
+	}
+	if ks.pool != nil {
+		ks.pool.close()
+		ks.pool = nil
+	}
+}
@@ -0,0 +1,43 @@
+package keysort
+
+import (
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPrimedKeysortPooledReusesPool(t *testing.T) {
+	const parallelism = 3
+	specimen := ByIntKeyHalfErrors{GenSpecimen(SPECIMEN_SIZE)}
+
+	ks := PrimedKeysortPooled(specimen, parallelism)
+	defer ks.Close()
+	pool := ks.pool
+
+	for retry := 0; retry < 3; retry++ {
+		ks.RetryFailed(parallelism)
+	}
+
+	if ks.pool != pool {
+		t.Errorf("RetryFailed replaced the worker pool")
+	}
+	if started := atomic.LoadInt64(&pool.started); started != parallelism {
+		t.Errorf("Expected %d goroutines started, got %d", parallelism, started)
+	}
+}
+
+func TestPrimedKeysortPooledSorts(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	ks := PrimedKeysortPooled(specimen, -1)
+	ks.Close()
+	ks.Close()
+
+	if memoized := countMemoized(ks); memoized != SPECIMEN_SIZE {
+		t.Errorf("Expected all %d keys memoized, got %d", SPECIMEN_SIZE, memoized)
+	}
+	sort.Sort(ks)
+	if !sort.IsSorted(specimen) {
+		t.Errorf("PrimedKeysortPooled failed for ByIntKey")
+	}
+}
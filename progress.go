@@ -0,0 +1,52 @@
+package keysort
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// PrimedKeysortProgress is like PrimedKeysort, but reports progress by calling
+// onProgress with the number of keys computed so far, and the total.
+//
+// onProgress is only ever called from a single goroutine, so it need not be
+// safe for concurrent use. If it is slow, consecutive updates are coalesced
+// rather than holding up the goroutines computing keys, so it may not see
+// every value of done. The final call always reports done == total, and has
+// returned by the time PrimedKeysortProgress does.
+func PrimedKeysortProgress(wrapped Interface, parallelism int, onProgress func(done, total int)) *keySortable {
+	ks := Keysort(wrapped)
+	total := ks.Len()
+
+	var done int64
+	// notify is buffered so that workers never wait on a slow onProgress.
+	notify := make(chan struct{}, 1)
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+		reported := -1
+		for range notify {
+			reported = int(atomic.LoadInt64(&done))
+			onProgress(reported, total)
+		}
+		// Every key signals after it is counted, so the last update has
+		// already reported total unless there were no keys at all.
+		if reported != total {
+			onProgress(total, total)
+		}
+	}()
+
+	ks.memoizeWith(context.Background(), parallelism, ks.allIndexes, func(i int) {
+		ks.Key(i)
+		atomic.AddInt64(&done, 1)
+		select {
+		case notify <- struct{}{}:
+		default:
+			// An update is already pending, and will see this key.
+		}
+	})
+
+	close(notify)
+	<-finished
+	return ks
+}
@@ -0,0 +1,50 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestPrimedKeysortProgress(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	events := [][2]int{}
+
+	ks := PrimedKeysortProgress(specimen, -1, func(done, total int) {
+		// Be slow, to check that updates are coalesced rather than blocking.
+		time.Sleep(time.Millisecond)
+		events = append(events, [2]int{done, total})
+	})
+
+	if len(events) == 0 {
+		t.Fatalf("Expected progress events")
+	}
+	for n, event := range events {
+		if event[1] != SPECIMEN_SIZE {
+			t.Errorf("Expected total %d, got %d", SPECIMEN_SIZE, event[1])
+		}
+		if n > 0 && event[0] < events[n-1][0] {
+			t.Errorf("Progress went backwards: %v", events)
+		}
+	}
+	if last := events[len(events)-1]; last[0] != SPECIMEN_SIZE {
+		t.Errorf("Expected last event to report %d done, got %d", SPECIMEN_SIZE, last[0])
+	}
+
+	sort.Sort(ks)
+	if !sort.IsSorted(specimen) {
+		t.Errorf("PrimedKeysortProgress failed for ByIntKey")
+	}
+}
+
+func TestPrimedKeysortProgressEmpty(t *testing.T) {
+	events := [][2]int{}
+
+	PrimedKeysortProgress(ByIntKey{}, -1, func(done, total int) {
+		events = append(events, [2]int{done, total})
+	})
+
+	if len(events) != 1 || events[0] != [2]int{0, 0} {
+		t.Errorf("Expected a single 0 of 0 event, got %v", events)
+	}
+}
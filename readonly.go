@@ -0,0 +1,42 @@
+package keysort
+
+// ReadOnlyInterface is like Interface, for a container that cannot be
+// swapped, such as a read-only view.
+type ReadOnlyInterface interface {
+	Key(i int) (interface{}, error)
+	LessVal(i, j interface{}) bool
+	Len() int
+}
+
+// readOnly adapts a ReadOnlyInterface to Interface by swapping a permutation of
+// its indices instead of its elements.
+type readOnly struct {
+	ReadOnlyInterface
+	// perm holds the index in the wrapped container of the element that is
+	// at each position of the sorted view.
+	perm []int
+}
+
+// Key delegates to the wrapped Key, at the index that has been moved to i.
+func (r readOnly) Key(i int) (interface{}, error) {
+	return r.ReadOnlyInterface.Key(r.perm[i])
+}
+
+// Swap swaps the permutation only.
+func (r readOnly) Swap(i, j int) {
+	r.perm[i], r.perm[j] = r.perm[j], r.perm[i]
+}
+
+// KeysortReadOnly creates a keySortable over a container that is never
+// mutated. Sorting it computes the sorted order from the memoized keys, which
+// can then be read with SortedIndices.
+func KeysortReadOnly(wrapped ReadOnlyInterface) *keySortable {
+	if wrapped == nil {
+		panic("keysort: nil Interface")
+	}
+	perm := make([]int, wrapped.Len())
+	for i := range perm {
+		perm[i] = i
+	}
+	return Keysort(readOnly{wrapped, perm})
+}
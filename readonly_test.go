@@ -0,0 +1,60 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+)
+
+// ImmutableInts is a view of ints that has no Swap method.
+type ImmutableInts struct {
+	values []int
+}
+
+func (v ImmutableInts) Key(i int) (interface{}, error) { return v.values[i], nil }
+func (v ImmutableInts) LessVal(i, j interface{}) bool  { return i.(int) < j.(int) }
+func (v ImmutableInts) Len() int                       { return len(v.values) }
+
+func TestKeysortReadOnly(t *testing.T) {
+	values := []int{5, 3, 9, 1, 7}
+	view := ImmutableInts{values}
+
+	ks := KeysortReadOnly(view)
+	if err := ks.Sort(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []int{3, 1, 0, 4, 2}
+	for i, index := range ks.SortedIndices() {
+		if index != expected[i] {
+			t.Errorf("Expected permutation %v, got %v", expected, ks.SortedIndices())
+			break
+		}
+	}
+	for i, value := range []int{5, 3, 9, 1, 7} {
+		if values[i] != value {
+			t.Errorf("Container was mutated: %v", values)
+			break
+		}
+	}
+	if calls := ks.KeyCalls(); calls != len(values) {
+		t.Errorf("Expected %d Key calls, got %d", len(values), calls)
+	}
+}
+
+func TestKeysortReadOnlySpecimen(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	view := ImmutableInts{make([]int, len(specimen))}
+	for i := range specimen {
+		view.values[i] = specimen[i].IntKey
+	}
+
+	ks := KeysortReadOnly(view)
+	sort.Sort(ks)
+
+	indices := ks.SortedIndices()
+	for i := 1; i < len(indices); i++ {
+		if view.values[indices[i-1]] > view.values[indices[i]] {
+			t.Errorf("SortedIndices out of order at %d", i)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package keysort
+
+import (
+	"context"
+	"fmt"
+)
+
+// PrimedKeysortResumable is like PrimedKeysort, but calls checkpoint with a
+// snapshot of the keys memoized so far, by original index, as priming goes on,
+// so that they can be saved and passed to ResumeFrom after a crash. Keys whose
+// computation failed are left out of the snapshots, so that they are retried.
+//
+// Each snapshot is taken under the lock, so it is consistent. checkpoint is
+// only ever called from a single goroutine, and if it is slow, snapshots are
+// taken less often rather than holding up priming. A final snapshot of every
+// key is passed to checkpoint before PrimedKeysortResumable returns.
+func PrimedKeysortResumable(wrapped Interface, parallelism int, checkpoint func(memo map[int]interface{})) *keySortable {
+	ks := Keysort(wrapped)
+
+	// notify is buffered so that workers never wait on a slow checkpoint.
+	notify := make(chan struct{}, 1)
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+		for range notify {
+			checkpoint(ks.successfulMemo())
+		}
+		checkpoint(ks.successfulMemo())
+	}()
+
+	ks.memoizeWith(context.Background(), parallelism, ks.allIndexes, func(i int) {
+		ks.Key(i)
+		select {
+		case notify <- struct{}{}:
+		default:
+			// A snapshot is already pending, and will see this key.
+		}
+	})
+
+	close(notify)
+	<-finished
+	return ks
+}
+
+// successfulMemo returns a copy of every memoized key that did not fail, by
+// original index.
+func (ks *keySortable) successfulMemo() map[int]interface{} {
+	ks.Lock()
+	defer ks.Unlock()
+	snapshot := map[int]interface{}{}
+	for i := 0; i < ks.Len(); i++ {
+		if _, failed := ks.errors[i]; failed {
+			continue
+		}
+		if value, ok := ks.memo.Get(i); ok {
+			snapshot[i] = value
+		}
+	}
+	return snapshot
+}
+
+// ResumeFrom creates a keySortable over wrapped whose memo is seeded from memo,
+// as passed to the checkpoint function of PrimedKeysortResumable. wrapped must
+// hold the same elements, in the same order, as the container that was being
+// primed. An error is returned if memo holds a key for an index that wrapped
+// does not have.
+func ResumeFrom(wrapped Interface, memo map[int]interface{}) (*keySortable, error) {
+	ks := Keysort(wrapped)
+	for i, value := range memo {
+		if i < 0 || i >= ks.Len() {
+			return nil, fmt.Errorf("keysort: memo has a key for index %d, but Len() is %d", i, ks.Len())
+		}
+		ks.memo.Put(i, value)
+	}
+	return ks, nil
+}
@@ -0,0 +1,68 @@
+package keysort
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// ByIntKeyCrashes fails to compute the key of any element of index crashAt or
+// later, as if the process died part way through priming.
+type ByIntKeyCrashes struct {
+	ByIntKey
+	crashAt int
+}
+
+func (s ByIntKeyCrashes) Key(i int) (interface{}, error) {
+	if i >= s.crashAt {
+		return nil, fmt.Errorf("crashed")
+	}
+	return s.ByIntKey.Key(i)
+}
+
+func TestPrimedKeysortResumable(t *testing.T) {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	var saved map[int]interface{}
+	checkpoints := 0
+
+	PrimedKeysortResumable(ByIntKeyCrashes{ByIntKey{specimen}, SPECIMEN_SIZE / 2}, 1,
+		func(memo map[int]interface{}) {
+			checkpoints++
+			if len(memo) < len(saved) {
+				t.Errorf("Checkpoint went backwards from %d to %d keys", len(saved), len(memo))
+			}
+			saved = memo
+		})
+
+	if checkpoints == 0 {
+		t.Fatalf("Expected checkpoints")
+	}
+	if len(saved) != SPECIMEN_SIZE/2 {
+		t.Fatalf("Expected %d keys in the last checkpoint, got %d", SPECIMEN_SIZE/2, len(saved))
+	}
+
+	var count int64
+	counted := ByIntKeyCounted{specimen, &count}
+	ks, err := ResumeFrom(counted, saved)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	ks.memoize(-1, ks.allIndexes)
+	sort.Sort(ks)
+
+	if count != SPECIMEN_SIZE-SPECIMEN_SIZE/2 {
+		t.Errorf("Expected only the remaining %d keys to be computed, got %d",
+			SPECIMEN_SIZE-SPECIMEN_SIZE/2, count)
+	}
+	if !sort.IsSorted(ByIntKey{specimen}) {
+		t.Errorf("Resumed sort failed")
+	}
+}
+
+func TestResumeFromOutOfRange(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	if _, err := ResumeFrom(specimen, map[int]interface{}{SPECIMEN_SIZE: 1}); err == nil {
+		t.Errorf("Expected an error for an index out of range")
+	}
+}
@@ -0,0 +1,89 @@
+package keysort
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// RetryPolicy says how PrimedKeysortRetry retries a call to Key() that failed.
+type RetryPolicy struct {
+	// MaxAttempts is how many times Key() may be called for one element,
+	// including the first call. Values less than 1 mean 1.
+	MaxAttempts int
+	// Backoff is how long to wait before the second attempt. The wait doubles
+	// before each attempt after that.
+	Backoff time.Duration
+	// Retryable reports whether an error is worth retrying. If it is nil,
+	// every error is.
+	Retryable func(error) bool
+}
+
+// PrimedKeysortRetry is like PrimedKeysort, but a key that fails is retried as
+// policy allows, by the goroutine that computed it, before its error is
+// recorded. The policy also applies to keys computed later during the sort, or
+// by RetryFailed. Each attempt counts towards KeyCalls(). Keys computed by
+// KeyBatch, if wrapped implements BatchKeyer, are not retried.
+func PrimedKeysortRetry(wrapped Interface, parallelism int, policy RetryPolicy) *keySortable {
+	ks := Keysort(wrapped)
+	ks.retry = &policy
+	ks.memoize(parallelism, ks.allIndexes)
+	return ks
+}
+
+// retryKey retries the key of the element currently at index i, whose first
+// attempt failed with err, until it succeeds, fails with an error that is not
+// retryable, or runs out of attempts. A context given to KeysortContext cuts
+// the backoff short, and stops the retries.
+func (ks *keySortable) retryKey(i int, err error) (interface{}, error) {
+	policy := ks.retry
+	var value interface{}
+	backoff := policy.Backoff
+	for attempt := 1; attempt < policy.MaxAttempts; attempt++ {
+		retry, classifyErr := ks.retryable(i, err)
+		if classifyErr != nil {
+			return nil, classifyErr
+		}
+		if !retry {
+			break
+		}
+		if !ks.sleep(backoff) {
+			break
+		}
+		backoff *= 2
+		if value, err = ks.attemptKey(i); err == nil {
+			return value, nil
+		}
+	}
+	return value, err
+}
+
+// retryable reports whether policy.Retryable allows err, from the key of the
+// element currently at index i, to be retried. A panic in Retryable is
+// returned as a KeyPanicError.
+func (ks *keySortable) retryable(i int, err error) (retry bool, classifyErr error) {
+	if ks.retry.Retryable == nil {
+		return true, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			retry, classifyErr = false, KeyPanicError{Index: ks.swaps[i], Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return ks.retry.Retryable(err), nil
+}
+
+// sleep waits for d, reporting false if ks's context was cancelled first.
+func (ks *keySortable) sleep(d time.Duration) bool {
+	if ks.ctx == nil {
+		time.Sleep(d)
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ks.ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,100 @@
+package keysort
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("rate limited")
+
+// ByIntKeyFlaky fails the first failures calls to Key for each element with
+// errTransient.
+type ByIntKeyFlaky struct {
+	ByIntKey
+	failures int
+	lock     *sync.Mutex
+	calls    map[int]int
+}
+
+func newByIntKeyFlaky(failures int) ByIntKeyFlaky {
+	specimen := GenSpecimen(SPECIMEN_SIZE)
+	for i := range specimen {
+		specimen[i].NotKey = i
+	}
+	return ByIntKeyFlaky{ByIntKey{specimen}, failures, &sync.Mutex{}, map[int]int{}}
+}
+
+func (s ByIntKeyFlaky) Key(i int) (interface{}, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.calls[s.At(i).NotKey]++
+	if s.calls[s.At(i).NotKey] <= s.failures {
+		return nil, errTransient
+	}
+	return s.ByIntKey.Key(i)
+}
+
+func TestPrimedKeysortRetryPolicy(t *testing.T) {
+	specimen := newByIntKeyFlaky(2)
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		Retryable:   func(err error) bool { return err == errTransient },
+	}
+
+	ks := PrimedKeysortRetry(specimen, -1, policy)
+
+	if err := ks.Errors(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !ks.FullyPrimed() {
+		t.Errorf("Expected every key to be memoized")
+	}
+	if value, _ := ks.Peek(0); value != specimen.At(0).IntKey {
+		t.Errorf("Expected %d to be memoized, got %v", specimen.At(0).IntKey, value)
+	}
+	if ks.KeyCalls() != 3*SPECIMEN_SIZE {
+		t.Errorf("Expected %d key calls, got %d", 3*SPECIMEN_SIZE, ks.KeyCalls())
+	}
+}
+
+func TestPrimedKeysortRetryGivesUp(t *testing.T) {
+	specimen := newByIntKeyFlaky(2)
+
+	ks := PrimedKeysortRetry(specimen, -1, RetryPolicy{MaxAttempts: 2})
+
+	if !errors.Is(ks.Errors(), errTransient) {
+		t.Errorf("Expected the transient errors to remain, got %v", ks.Errors())
+	}
+	if ks.KeyCalls() != 2*SPECIMEN_SIZE {
+		t.Errorf("Expected %d key calls, got %d", 2*SPECIMEN_SIZE, ks.KeyCalls())
+	}
+}
+
+func TestPrimedKeysortRetryNotRetryable(t *testing.T) {
+	specimen := newByIntKeyFlaky(1)
+	policy := RetryPolicy{MaxAttempts: 3, Retryable: func(error) bool { return false }}
+
+	ks := PrimedKeysortRetry(specimen, -1, policy)
+
+	if ks.Errors() == nil {
+		t.Errorf("Errors were expected.")
+	}
+	if ks.KeyCalls() != SPECIMEN_SIZE {
+		t.Errorf("Expected no retries, got %d key calls", ks.KeyCalls())
+	}
+}
+
+func TestPrimedKeysortRetryablePanics(t *testing.T) {
+	specimen := newByIntKeyFlaky(1)
+	policy := RetryPolicy{MaxAttempts: 3, Retryable: func(error) bool { panic("bad classifier") }}
+
+	ks := PrimedKeysortRetry(specimen, -1, policy)
+
+	var panicErr KeyPanicError
+	if !errors.As(ks.Errors(), &panicErr) || panicErr.Value != "bad classifier" {
+		t.Errorf("Expected a KeyPanicError, got %v", ks.Errors())
+	}
+}
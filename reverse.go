@@ -0,0 +1,46 @@
+package keysort
+
+import "context"
+
+// reverse wraps an Interface, flipping the order in which LessVal compares
+// keys.
+type reverse struct {
+	Interface
+}
+
+// Reverse returns an Interface that sorts wrapped in descending order.
+// Key, Swap and Len are passed through unchanged, so keys are still
+// memoized as usual when the result is passed to Keysort.
+func Reverse(wrapped Interface) Interface {
+	return reverse{wrapped}
+}
+
+// LessVal delegates to the wrapped LessVal with its arguments swapped.
+func (r reverse) LessVal(i, j interface{}) bool {
+	return r.Interface.LessVal(j, i)
+}
+
+// KeyCtx passes ctx on to the wrapped KeyCtx, if there is one.
+func (r reverse) KeyCtx(ctx context.Context, i int) (interface{}, error) {
+	return keyCtx(ctx, r.Interface, i)
+}
+
+func (r reverse) inner() Interface { return r.Interface }
+
+// DescendingBy creates a keySortable that sorts wrapped in descending order,
+// like By(Reverse(wrapped)). Rather than wrapping it, the keySortable flips its
+// own comparisons, so any optional interfaces wrapped implements, such as
+// KeyNormalizer or LessValErr, are still used.
+func DescendingBy(wrapped Interface) *keySortable {
+	ks := By(wrapped)
+	ks.descending = true
+	return ks
+}
+
+// PrimedDescendingBy is like DescendingBy, but memoizes every key using
+// parallelism goroutines, as for PrimedBy.
+func PrimedDescendingBy(wrapped Interface, parallelism int) *keySortable {
+	ks := PrimedBy(wrapped, parallelism)
+	ks.descending = true
+	return ks
+}
@@ -0,0 +1,57 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestReverseByIntKey(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	sort.Sort(Keysort(Reverse(specimen)))
+
+	if !sort.IsSorted(sort.Reverse(specimen)) {
+		t.Errorf("Reverse failed for ByIntKey")
+	}
+}
+
+func TestReverseByStringKey(t *testing.T) {
+	specimen := ByStringKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	sort.Sort(PrimedKeysort(Reverse(specimen), -1))
+
+	if !sort.IsSorted(sort.Reverse(specimen)) {
+		t.Errorf("Reverse failed for ByStringKey")
+	}
+}
+
+// The descending sorts are not stable, so these tests only check the order of
+// keys, never the order of elements with equal keys.
+
+func TestDescendingByIntKey(t *testing.T) {
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	ks := DescendingBy(specimen)
+	sort.Sort(ks)
+
+	if !sort.IsSorted(sort.Reverse(specimen)) {
+		t.Errorf("DescendingBy failed for ByIntKey")
+	}
+	if ks.KeyCalls() > SPECIMEN_SIZE {
+		t.Errorf("Key() called %d times, expected at most %d", ks.KeyCalls(), SPECIMEN_SIZE)
+	}
+}
+
+func TestPrimedDescendingByStringKey(t *testing.T) {
+	specimen := ByStringKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	ks := PrimedDescendingBy(specimen, -1)
+	sort.Sort(ks)
+
+	if !sort.IsSorted(sort.Reverse(specimen)) {
+		t.Errorf("PrimedDescendingBy failed for ByStringKey")
+	}
+	if ks.KeyCalls() != SPECIMEN_SIZE {
+		t.Errorf("Key() called %d times, expected exactly %d", ks.KeyCalls(), SPECIMEN_SIZE)
+	}
+}
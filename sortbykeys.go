@@ -0,0 +1,17 @@
+package keysort
+
+import "sort"
+
+// SortByKeys sorts a container whose keys have already been computed. keys[i]
+// is the key of the element at position i, and swap swaps two elements of the
+// container. There is no memoization layer at all: the indices are sorted by
+// keys, and the resulting permutation applied with swap. keys itself is not
+// modified.
+func SortByKeys(swap func(i, j int), keys []interface{}, less Comparator) {
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Sort(indexSorter{order, keys, less})
+	applyPermutation(swap, order)
+}
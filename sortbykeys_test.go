@@ -0,0 +1,39 @@
+package keysort
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSortByKeys(t *testing.T) {
+	elements := make([]string, SPECIMEN_SIZE)
+	keys := make([]interface{}, SPECIMEN_SIZE)
+	for i := range keys {
+		key := rand.Intn(SPECIMEN_SIZE)
+		keys[i] = key
+		elements[i] = string(rune('a' + key))
+	}
+
+	SortByKeys(func(i, j int) { elements[i], elements[j] = elements[j], elements[i] },
+		keys, func(a, b interface{}) bool { return a.(int) < b.(int) })
+
+	if !sort.StringsAreSorted(elements) {
+		t.Errorf("SortByKeys failed: %v", elements)
+	}
+}
+
+func BenchmarkSortByKeys(b *testing.B) {
+	keys := make([]interface{}, BENCHMARK_SIZE)
+	for i := range keys {
+		keys[i] = BENCHMARK_SIZE - i
+	}
+	elements := make([]int, BENCHMARK_SIZE)
+	swap := func(i, j int) { elements[i], elements[j] = elements[j], elements[i] }
+	less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		SortByKeys(swap, keys, less)
+	}
+}
@@ -0,0 +1,30 @@
+package keysort
+
+import "context"
+
+// subrange presents the elements [lo, hi) of an Interface as an Interface of
+// their own.
+type subrange struct {
+	Interface
+	lo, hi int
+}
+
+// KeysortRange is like Keysort, but only sorts the elements of wrapped in
+// [lo, hi), leaving the rest untouched. Indices reported by the keySortable,
+// such as those in its errors and SortedIndices, are relative to lo.
+// KeysortRange panics if lo and hi are not a valid range of wrapped.
+func KeysortRange(wrapped Interface, lo, hi int) *keySortable {
+	if lo < 0 || hi < lo || hi > wrapped.Len() {
+		panic("keysort: KeysortRange out of range")
+	}
+	return Keysort(subrange{wrapped, lo, hi})
+}
+
+func (s subrange) Key(i int) (interface{}, error) { return s.Interface.Key(s.lo + i) }
+func (s subrange) Swap(i, j int)                  { s.Interface.Swap(s.lo+i, s.lo+j) }
+func (s subrange) Len() int                       { return s.hi - s.lo }
+func (s subrange) inner() Interface               { return s.Interface }
+
+func (s subrange) KeyCtx(ctx context.Context, i int) (interface{}, error) {
+	return keyCtx(ctx, s.Interface, s.lo+i)
+}
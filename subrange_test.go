@@ -0,0 +1,22 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestKeysortRange(t *testing.T) {
+	original := GenSpecimen(SPECIMEN_SIZE)
+	specimen := ByIntKey{append(SpecimenSliceSorter{}, original...)}
+
+	sort.Sort(KeysortRange(specimen, 5, 15))
+
+	for i := 0; i < SPECIMEN_SIZE; i++ {
+		if (i < 5 || i >= 15) && specimen.At(i) != original[i] {
+			t.Errorf("Element %d outside the range was moved", i)
+		}
+	}
+	if !sort.IsSorted(ByIntKey{specimen.SpecimenSliceSorter[5:15]}) {
+		t.Errorf("KeysortRange failed to sort [5, 15)")
+	}
+}
@@ -0,0 +1,41 @@
+package keysort
+
+import (
+	"fmt"
+	"time"
+)
+
+// timeKeys wraps a KeyProvider whose keys are time.Time values.
+type timeKeys struct {
+	KeyProvider
+}
+
+// Key strips the monotonic clock reading from the wrapped key, so that every
+// comparison uses wall-clock time. Otherwise two times with monotonic
+// readings would be compared by those, while comparisons against a time
+// without one would not, and the order could be inconsistent.
+func (t timeKeys) Key(i int) (interface{}, error) {
+	value, err := t.KeyProvider.Key(i)
+	if err != nil {
+		return value, err
+	}
+	key, ok := value.(time.Time)
+	if !ok {
+		return value, fmt.Errorf("keysort: Key(%d) is a %T, not a time.Time", i, value)
+	}
+	return key.Round(0), nil
+}
+
+// LessVal reports whether i is before j.
+func (t timeKeys) LessVal(i, j interface{}) bool {
+	return i.(time.Time).Before(j.(time.Time))
+}
+
+// KeysortTime creates a keySortable over wrapped, whose keys must be
+// time.Time, that sorts them chronologically. Times are compared as instants,
+// so times that are equal but in different locations are equal keys, and keep
+// their original order under Stable. A key that is not a time.Time is recorded
+// as an error.
+func KeysortTime(wrapped KeyProvider) *keySortable {
+	return Keysort(timeKeys{wrapped})
+}
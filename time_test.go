@@ -0,0 +1,52 @@
+package keysort
+
+import (
+	"testing"
+	"time"
+)
+
+// ByTimestamp sorts by its own times.
+type ByTimestamp []time.Time
+
+func (s ByTimestamp) Key(i int) (interface{}, error) { return s[i], nil }
+func (s ByTimestamp) Swap(i, j int)                  { s[i], s[j] = s[j], s[i] }
+func (s ByTimestamp) Len() int                       { return len(s) }
+
+func TestKeysortTime(t *testing.T) {
+	base := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	tokyo := time.FixedZone("Tokyo", 9*60*60)
+	now := time.Now()
+	specimen := ByTimestamp{
+		base.Add(time.Hour),
+		// The same instant as base, in other locations.
+		base.In(tokyo),
+		base,
+		base.Add(-time.Hour),
+		base.Local(),
+		// now has a monotonic clock reading, unlike the others.
+		now,
+		now.Add(-time.Second),
+	}
+
+	if err := KeysortTime(specimen).Stable(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []time.Time{
+		base.Add(-time.Hour), base.In(tokyo), base, base.Local(), base.Add(time.Hour),
+		now.Add(-time.Second), now,
+	}
+	for i := range expected {
+		if !specimen[i].Equal(expected[i]) || specimen[i].Location() != expected[i].Location() {
+			t.Errorf("Expected %v at %d, got %v", expected[i], i, specimen[i])
+		}
+	}
+}
+
+func TestKeysortTimeWrongType(t *testing.T) {
+	ks := KeysortTime(ByIntKey{GenSpecimen(SPECIMEN_SIZE)})
+
+	if err := ks.Sort(); err == nil {
+		t.Errorf("Expected an error for keys that are not times")
+	}
+}
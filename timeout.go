@@ -0,0 +1,67 @@
+package keysort
+
+import (
+	"fmt"
+	"time"
+)
+
+// KeyTimeoutError is recorded against an index whose Key() took longer than
+// the timeout given to PrimedKeysortTimeout.
+type KeyTimeoutError struct {
+	Index   int
+	Timeout time.Duration
+}
+
+// Error returns a string representation of this error.
+func (e KeyTimeoutError) Error() string {
+	return fmt.Sprintf("Key(%d) timed out after %s", e.Index, e.Timeout)
+}
+
+// PrimedKeysortTimeout is like PrimedKeysort, but abandons any single call to
+// wrapped.Key() that takes longer than per, recording a KeyTimeoutError for
+// that index instead. The timeout also applies to keys computed later during
+// the sort, or by RetryFailed.
+//
+// Since Key() cannot be interrupted, an abandoned call keeps running in its
+// own goroutine until it returns, and its result is then discarded. It still
+// counts against any limit on concurrent calls to Key() until then. Meanwhile
+// the sort goes on swapping elements, so Key() must tolerate its element being
+// moved while it runs.
+func PrimedKeysortTimeout(wrapped Interface, parallelism int, per time.Duration) *keySortable {
+	ks := Keysort(wrapped)
+	ks.timeout = per
+	ks.memoize(parallelism, ks.allIndexes)
+	return ks
+}
+
+// keyResult carries the return values of wrapped.Key() across a channel.
+type keyResult struct {
+	value interface{}
+	err   error
+}
+
+// callKeyTimeout runs wrapped.Key(i) in its own goroutine, and gives up on it
+// after ks.timeout. done is called once wrapped.Key(i) returns, whether or not
+// it was abandoned. The goroutine never touches ks.swaps, which the sort may
+// be swapping by then.
+func (ks *keySortable) callKeyTimeout(i int, done func()) (interface{}, error) {
+	originalIndex := ks.swaps[i]
+	// Buffered, so that an abandoned goroutine can always deliver its result
+	// and exit.
+	result := make(chan keyResult, 1)
+	go func() {
+		defer done()
+		value, err := ks.safeKey(i, originalIndex)
+		result <- keyResult{value, err}
+	}()
+
+	timer := time.NewTimer(ks.timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-result:
+		return r.value, r.err
+	case <-timer.C:
+		return nil, KeyTimeoutError{Index: originalIndex, Timeout: ks.timeout}
+	}
+}
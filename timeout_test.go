@@ -0,0 +1,81 @@
+package keysort
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPrimedKeysortTimeout(t *testing.T) {
+	const timeout = 20 * time.Millisecond
+	specimen := ByIntKeyHangs{GenSpecimen(SPECIMEN_SIZE), map[int]bool{3: true, 7: true}, 5 * timeout}
+
+	ks := PrimedKeysortTimeout(specimen, -1, timeout)
+
+	err := ks.Errors()
+	if err == nil {
+		t.Fatalf("Errors were expected.")
+	}
+	errors := err.(PrimingError).Errors
+	if len(errors) != len(specimen.hangs) {
+		t.Errorf("Expected exactly %d errors, got %d.", len(specimen.hangs), len(errors))
+	}
+	for i := range specimen.hangs {
+		if e, ok := errors[i].(KeyTimeoutError); !ok || e.Index != i {
+			t.Errorf("Expected a KeyTimeoutError for index %d, got %v", i, errors[i])
+		}
+	}
+
+	// Let the abandoned calls finish, and check their results were discarded.
+	time.Sleep(2 * specimen.delay)
+	ks.Lock()
+	defer ks.Unlock()
+	for i := range specimen.hangs {
+		if value, _ := ks.memo.Get(i); value != nil {
+			t.Errorf("Abandoned Key(%d) overwrote the memo with %v", i, value)
+		}
+	}
+}
+
+// ByIntKeyHangs sleeps for delay before returning the key of any index in
+// hangs.
+type ByIntKeyHangs struct {
+	SpecimenSliceSorter
+	hangs map[int]bool
+	delay time.Duration
+}
+
+func (s ByIntKeyHangs) LessVal(i, j interface{}) bool {
+	return i.(int) < j.(int)
+}
+
+func (s ByIntKeyHangs) Key(i int) (interface{}, error) {
+	if s.hangs[i] {
+		time.Sleep(s.delay)
+	}
+	return s.At(i).IntKey, nil
+}
+
+func TestTimeoutHoldsPoolSlot(t *testing.T) {
+	const timeout = 20 * time.Millisecond
+	specimen := ByIntKeyHangs{GenSpecimen(SPECIMEN_SIZE), map[int]bool{0: true}, 5 * timeout}
+	pool := NewKeysortPool(1)
+	ks := pool.Keysort(specimen)
+	ks.timeout = timeout
+
+	ks.Key(0)
+	if ks.Errors() == nil {
+		t.Fatalf("Expected Key(0) to time out")
+	}
+	// The abandoned call is still running, so it still holds the only slot.
+	if len(pool.sem) != 1 {
+		t.Errorf("Expected the abandoned call to hold its slot")
+	}
+
+	deadline := time.Now().Add(10 * specimen.delay)
+	for len(pool.sem) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if len(pool.sem) != 0 {
+		t.Errorf("Expected the slot to be released once the abandoned call returned")
+	}
+}
@@ -0,0 +1,56 @@
+package keysort
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// TopK primes the keys of wrapped using parallelism goroutines, and returns the
+// indices of the k elements with the smallest keys, smallest first. If k is
+// larger than wrapped.Len(), every index is returned. wrapped is never swapped.
+// If any key fails, the errors are returned instead.
+func TopK(wrapped Interface, k int, parallelism int) ([]int, error) {
+	ks := PrimedKeysort(wrapped, parallelism)
+	if err := ks.Errors(); err != nil {
+		return nil, err
+	}
+
+	keys := make([]interface{}, ks.Len())
+	for i := range keys {
+		keys[i] = ks.Key(i)
+	}
+	if k > len(keys) {
+		k = len(keys)
+	}
+	if k <= 0 {
+		return []int{}, nil
+	}
+
+	// Keep the k smallest seen so far in a max-heap, so that the largest of
+	// them is the one to evict.
+	h := &maxIndexHeap{indexSorter{make([]int, 0, k), keys, wrapped.LessVal}}
+	for i := range keys {
+		if h.Len() < k {
+			heap.Push(h, i)
+		} else if wrapped.LessVal(keys[i], keys[h.indices[0]]) {
+			h.indices[0] = i
+			heap.Fix(h, 0)
+		}
+	}
+
+	sort.Sort(h.indexSorter)
+	return h.indices, nil
+}
+
+// maxIndexHeap is a heap of indices whose root has the largest key.
+type maxIndexHeap struct {
+	indexSorter
+}
+
+func (h *maxIndexHeap) Less(i, j int) bool { return h.indexSorter.Less(j, i) }
+func (h *maxIndexHeap) Push(x interface{}) { h.indices = append(h.indices, x.(int)) }
+func (h *maxIndexHeap) Pop() interface{} {
+	last := h.indices[len(h.indices)-1]
+	h.indices = h.indices[:len(h.indices)-1]
+	return last
+}
@@ -0,0 +1,42 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestTopK(t *testing.T) {
+	for _, k := range []int{0, 1, 5, SPECIMEN_SIZE, SPECIMEN_SIZE + 10} {
+		specimen := GenSpecimen(SPECIMEN_SIZE)
+		original := append(SpecimenSliceSorter{}, specimen...)
+
+		indices, err := TopK(ByIntKey{specimen}, k, -1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		expected := min(k, SPECIMEN_SIZE)
+		if len(indices) != expected {
+			t.Errorf("k=%d: expected %d indices, got %d", k, expected, len(indices))
+		}
+		// Compare the keys with a brute-force sort.
+		bruteForce := ByIntKey{append(SpecimenSliceSorter{}, specimen...)}
+		sort.Sort(bruteForce)
+		for n, i := range indices {
+			if specimen[i].IntKey != bruteForce.At(n).IntKey {
+				t.Errorf("k=%d: expected key %d at %d, got %d", k, bruteForce.At(n).IntKey, n, specimen[i].IntKey)
+			}
+		}
+		for i := range specimen {
+			if specimen[i] != original[i] {
+				t.Fatalf("k=%d: TopK moved element %d", k, i)
+			}
+		}
+	}
+}
+
+func TestTopKErrors(t *testing.T) {
+	if _, err := TopK(ByIntKeyHalfErrors{GenSpecimen(SPECIMEN_SIZE)}, 3, -1); err == nil {
+		t.Errorf("Errors were expected.")
+	}
+}
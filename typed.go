@@ -0,0 +1,157 @@
+package keysort
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// IntKeyInterface is like Interface for containers whose keys are ints, which
+// are compared with <. Keys are memoized without being boxed in an interface{}.
+type IntKeyInterface interface {
+	Key(i int) (int, error)
+	Swap(i, j int)
+	Len() int
+}
+
+// StringKeyInterface is like IntKeyInterface, for string keys.
+type StringKeyInterface interface {
+	Key(i int) (string, error)
+	Swap(i, j int)
+	Len() int
+}
+
+// typedKey is the set of key types that have a typed fast path.
+type typedKey interface {
+	int | string
+}
+
+// typedKeySortable implements sort.Interface for a container with keys of type
+// K, memoizing them in a []K indexed by original index. This is meant to be
+// created by calling KeysortInt or KeysortString.
+type typedKeySortable[K typedKey] struct {
+	key  func(i int) (K, error)
+	swap func(i, j int)
+	// swaps keeps track of the original index of the element currently at
+	// each position.
+	swaps []int
+	// keys holds the memoized key of each original index, once computed[i]
+	// is set.
+	keys     []K
+	computed []bool
+	// errors is a map of original indices to error objects encountered by this object.
+	errors map[int]error
+	// lock coordinates access to keys, computed and errors.
+	sync.Mutex
+}
+
+// KeysortInt is like Keysort, for containers with int keys. Memoized keys are
+// stored in a []int, so sorting does not allocate once per key.
+func KeysortInt(wrapped IntKeyInterface) *typedKeySortable[int] {
+	return newTypedKeySortable(wrapped.Len(), wrapped.Key, wrapped.Swap)
+}
+
+// KeysortString is like KeysortInt, for containers with string keys.
+func KeysortString(wrapped StringKeyInterface) *typedKeySortable[string] {
+	return newTypedKeySortable(wrapped.Len(), wrapped.Key, wrapped.Swap)
+}
+
+func newTypedKeySortable[K typedKey](n int, key func(i int) (K, error), swap func(i, j int)) *typedKeySortable[K] {
+	swaps := make([]int, n)
+	for i := range swaps {
+		swaps[i] = i
+	}
+	return &typedKeySortable[K]{
+		key:      key,
+		swap:     swap,
+		swaps:    swaps,
+		keys:     make([]K, n),
+		computed: make([]bool, n),
+		errors:   make(map[int]error, errorsCapacity(n)),
+	}
+}
+
+// Less is designed to implement sort.Interface. It compares the keys of i and
+// j, computing them first if needed. Once any key has failed, it always returns
+// false.
+func (ks *typedKeySortable[K]) Less(i, j int) bool {
+	IValue := ks.Key(i)
+	JValue := ks.Key(j)
+	if ks.hasErrors() {
+		return false
+	}
+	return IValue < JValue
+}
+
+// Key returns the key of the element currently at index i, computing it only
+// the first time it is needed. A key that failed is not memoized, and the zero K
+// is returned for it.
+func (ks *typedKeySortable[K]) Key(i int) K {
+	originalIndex := ks.swaps[i]
+	var zero K
+
+	ks.Lock()
+	if ks.computed[originalIndex] {
+		defer ks.Unlock()
+		return ks.keys[originalIndex]
+	}
+	if _, failed := ks.errors[originalIndex]; failed {
+		ks.Unlock()
+		return zero
+	}
+	// Release lock while calculating value of Key().
+	ks.Unlock()
+	value, err := ks.safeKey(i)
+
+	ks.Lock()
+	defer ks.Unlock()
+	if err != nil {
+		ks.errors[originalIndex] = err
+		return zero
+	}
+	ks.keys[originalIndex] = value
+	ks.computed[originalIndex] = true
+	delete(ks.errors, originalIndex)
+	return value
+}
+
+// safeKey computes the key of the element currently at index i, converting a
+// panic into a KeyPanicError.
+func (ks *typedKeySortable[K]) safeKey(i int) (value K, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var zero K
+			value, err = zero, KeyPanicError{Index: ks.swaps[i], Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return ks.key(i)
+}
+
+// Len is designed to implement sort.Interface.
+func (ks *typedKeySortable[K]) Len() int {
+	return len(ks.swaps)
+}
+
+// Swap is designed to implement sort.Interface.
+// Delegates the call to wrapped.Swap, while keeping track of the swaps.
+func (ks *typedKeySortable[K]) Swap(i, j int) {
+	ks.swaps[i], ks.swaps[j] = ks.swaps[j], ks.swaps[i]
+	ks.swap(i, j)
+}
+
+// hasErrors is a cheaper way to test whether Errors() is non-nil.
+func (ks *typedKeySortable[K]) hasErrors() bool {
+	ks.Lock()
+	defer ks.Unlock()
+	return len(ks.errors) != 0
+}
+
+// Errors returns a non-nil error if one or more of the Key functions returned
+// an error.
+func (ks *typedKeySortable[K]) Errors() error {
+	ks.Lock()
+	defer ks.Unlock()
+	if len(ks.errors) == 0 {
+		return nil
+	}
+	return PrimingError{Errors: copyErrors(ks.errors)}
+}
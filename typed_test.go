@@ -0,0 +1,109 @@
+package keysort
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+func TestKeysortInt(t *testing.T) {
+	specimen := TypedByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	ks := KeysortInt(specimen)
+	sort.Sort(ks)
+
+	if !sort.IsSorted(ByIntKey{specimen.SpecimenSliceSorter}) {
+		t.Errorf("KeysortInt failed to sort")
+	}
+	if err := ks.Errors(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+func TestKeysortString(t *testing.T) {
+	specimen := TypedByStringKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	sort.Sort(KeysortString(specimen))
+
+	if !sort.IsSorted(ByStringKey{specimen.SpecimenSliceSorter}) {
+		t.Errorf("KeysortString failed to sort")
+	}
+}
+
+func TestKeysortStringErrors(t *testing.T) {
+	specimen := TypedByStringKey{GenSpecimen(SPECIMEN_SIZE)}
+	failing := errors.New("failed")
+
+	ks := KeysortString(typedStringErrors{specimen, failing})
+	sort.Sort(ks)
+
+	primingError, ok := ks.Errors().(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError, got %v", ks.Errors())
+	}
+	if primingError.ErrorAt(1) != failing {
+		t.Errorf("Expected the error at index 1, got %v", primingError.Errors)
+	}
+}
+
+func TestKeysortStringErrorsNotMemoized(t *testing.T) {
+	specimen := TypedByStringKey{GenSpecimen(SPECIMEN_SIZE)}
+	ks := KeysortString(typedStringErrors{specimen, errors.New("failed")})
+	sort.Sort(ks)
+
+	// Element 1 has the key "aaa".
+	if ks.computed[1] {
+		t.Errorf("Expected the failed key not to be memoized")
+	}
+}
+
+func TestKeysortIntKeyPanics(t *testing.T) {
+	specimen := TypedByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	ks := KeysortInt(typedIntPanics{specimen})
+	sort.Sort(ks)
+
+	primingError, ok := ks.Errors().(PrimingError)
+	if !ok {
+		t.Fatalf("Expected a PrimingError, got %v", ks.Errors())
+	}
+	if _, ok := primingError.ErrorAt(0).(KeyPanicError); !ok {
+		t.Errorf("Expected a KeyPanicError at index 0, got %v", primingError.Errors)
+	}
+}
+
+type TypedByIntKey struct{ SpecimenSliceSorter }
+
+func (s TypedByIntKey) Key(i int) (int, error) {
+	return s.At(i).IntKey, nil
+}
+
+type TypedByStringKey struct{ SpecimenSliceSorter }
+
+func (s TypedByStringKey) Key(i int) (string, error) {
+	return s.At(i).StringKey, nil
+}
+
+// typedStringErrors fails on the key "aaa".
+type typedStringErrors struct {
+	TypedByStringKey
+	err error
+}
+
+func (s typedStringErrors) Key(i int) (string, error) {
+	key, _ := s.TypedByStringKey.Key(i)
+	if key == "aaa" {
+		return key, s.err
+	}
+	return key, nil
+}
+
+// typedIntPanics panics on the key 1.
+type typedIntPanics struct{ TypedByIntKey }
+
+func (s typedIntPanics) Key(i int) (int, error) {
+	key, _ := s.TypedByIntKey.Key(i)
+	if key == 1 {
+		panic("bad key")
+	}
+	return key, nil
+}
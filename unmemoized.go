@@ -0,0 +1,29 @@
+package keysort
+
+import "sort"
+
+// unmemoized adapts an Interface to sort.Interface without memoizing keys.
+type unmemoized struct {
+	Interface
+}
+
+// Unmemoized returns a sort.Interface that computes both keys afresh on every
+// call to Less, without any memo or locking. For very small containers with
+// cheap keys this can be faster than Keysort. If either key fails, Less reports
+// false, and the error is lost.
+func Unmemoized(wrapped Interface) sort.Interface {
+	return unmemoized{wrapped}
+}
+
+// Less compares the keys of the elements at i and j.
+func (u unmemoized) Less(i, j int) bool {
+	IValue, err := u.Key(i)
+	if err != nil {
+		return false
+	}
+	JValue, err := u.Key(j)
+	if err != nil {
+		return false
+	}
+	return u.LessVal(IValue, JValue)
+}
@@ -0,0 +1,16 @@
+package keysort
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestUnmemoized(t *testing.T) {
+	specimen := ByStringKey{GenSpecimen(SPECIMEN_SIZE)}
+
+	sort.Sort(Unmemoized(specimen))
+
+	if !sort.IsSorted(specimen) {
+		t.Errorf("Unmemoized failed for ByStringKey")
+	}
+}
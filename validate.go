@@ -0,0 +1,118 @@
+package keysort
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateSampleSize is how many elements Validate checks.
+const validateSampleSize = 8
+
+// ValidationError is returned by Validate, listing every problem it found.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error returns a string representation of this error.
+func (e ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("Interface failed validation.\n")
+	for _, problem := range e.Problems {
+		fmt.Fprintf(&b, "\t%s\n", problem)
+	}
+	return b.String()
+}
+
+// Validate checks that wrapped behaves as an Interface should, on a sample of
+// its first few elements: that Key succeeds, that LessVal accepts the keys
+// without panicking and is a strict ordering of them, and that Swap swaps
+// elements without changing Len. It is meant to be run in tests, to catch
+// mistakes in an Interface before they show up as a misordered sort.
+//
+// Validate swaps two elements of wrapped, and then swaps them back. If it finds
+// any problems, it returns a ValidationError describing each of them.
+func Validate(wrapped Interface) error {
+	problems := []string{}
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	n := wrapped.Len()
+	keys := make([]interface{}, min(n, validateSampleSize))
+	for i := range keys {
+		key, err := wrapped.Key(i)
+		if err != nil {
+			report("Key(%d) failed: %s", i, err)
+		}
+		keys[i] = key
+	}
+	if len(problems) != 0 {
+		// There is no point comparing keys that could not be computed.
+		return ValidationError{problems}
+	}
+
+	for i := range keys {
+		if less, err := safeLessVal(wrapped, keys[i], keys[i]); err != nil {
+			report("LessVal panicked on the key of %d (a %T): %v", i, keys[i], err)
+		} else if less {
+			report("LessVal reports the key of %d (%v) is less than itself", i, keys[i])
+		}
+		if i == 0 {
+			continue
+		}
+		forward, err := safeLessVal(wrapped, keys[i-1], keys[i])
+		if err != nil {
+			report("LessVal panicked on the keys of %d and %d: %v", i-1, i, err)
+			continue
+		}
+		backward, err := safeLessVal(wrapped, keys[i], keys[i-1])
+		if err != nil {
+			report("LessVal panicked on the keys of %d and %d: %v", i, i-1, err)
+			continue
+		}
+		if forward && backward {
+			report("LessVal reports the keys of %d (%v) and %d (%v) are each less than the other",
+				i-1, keys[i-1], i, keys[i])
+		}
+	}
+
+	if len(keys) >= 2 {
+		wrapped.Swap(0, 1)
+		if wrapped.Len() != n {
+			report("Swap(0, 1) changed Len() from %d to %d", n, wrapped.Len())
+		}
+		// The swap can only be seen if the two keys differ.
+		if differ(wrapped, keys[0], keys[1]) {
+			if swapped, err := wrapped.Key(0); err == nil && differ(wrapped, swapped, keys[1]) {
+				report("Swap(0, 1) did not move the element at 1 to 0")
+			}
+		}
+		wrapped.Swap(0, 1)
+	}
+
+	if len(problems) != 0 {
+		return ValidationError{problems}
+	}
+	return nil
+}
+
+// differ reports whether LessVal orders a and b either way, treating a panic
+// as an ordering, since it has already been reported.
+func differ(wrapped Interface, a, b interface{}) bool {
+	forward, err := safeLessVal(wrapped, a, b)
+	if forward || err != nil {
+		return true
+	}
+	backward, err := safeLessVal(wrapped, b, a)
+	return backward || err != nil
+}
+
+// safeLessVal calls wrapped.LessVal(a, b), converting a panic into an error.
+func safeLessVal(wrapped Interface, a, b interface{}) (less bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return wrapped.LessVal(a, b), nil
+}
@@ -0,0 +1,57 @@
+package keysort
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	// The first two keys of a specimen always differ, so the swap is checked.
+	specimen := ByIntKey{GenSpecimen(SPECIMEN_SIZE)}
+	original := append(SpecimenSliceSorter{}, specimen.SpecimenSliceSorter...)
+
+	if err := Validate(specimen); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	for i := range original {
+		if specimen.SpecimenSliceSorter[i] != original[i] {
+			t.Errorf("Validate left the container changed at %d", i)
+		}
+	}
+}
+
+// WrongKeyType returns string keys, but its LessVal expects ints.
+type WrongKeyType struct{ ByStringKey }
+
+func (s WrongKeyType) LessVal(i, j interface{}) bool { return i.(int) < j.(int) }
+
+// NonStrict reports that every key is less than every other.
+type NonStrict struct{ ByIntKey }
+
+func (s NonStrict) LessVal(i, j interface{}) bool { return true }
+
+// NoSwap does not swap.
+type NoSwap struct{ ByIntKey }
+
+func (s NoSwap) Swap(i, j int) {}
+
+func TestValidateBroken(t *testing.T) {
+	for name, test := range map[string]struct {
+		wrapped  Interface
+		expected string
+	}{
+		"wrong key type": {WrongKeyType{ByStringKey{GenSpecimen(SPECIMEN_SIZE)}}, "LessVal panicked"},
+		"non-strict":     {NonStrict{ByIntKey{GenSpecimen(SPECIMEN_SIZE)}}, "less than itself"},
+		"no swap":        {NoSwap{ByIntKey{GenSpecimen(SPECIMEN_SIZE)}}, "did not move"},
+		"key errors":     {ByStringKeyErrors{GenSpecimen(SPECIMEN_SIZE)}, "Key(1) failed"},
+	} {
+		err := Validate(test.wrapped)
+		if _, ok := err.(ValidationError); !ok {
+			t.Errorf("%s: expected a ValidationError, got %v", name, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected %q in %q", name, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package keysort
+
+import "container/heap"
+
+// WindowSort emits the items received from in in ascending order of key,
+// buffering up to window of them. Once the buffer is full, the item with the
+// smallest key is emitted as each new item arrives, and the rest are emitted in
+// order once in is closed, after which the returned channel is closed.
+//
+// The output is fully sorted if no item arrives more than window-1 places away
+// from its sorted position, and only nearly sorted otherwise. key is called
+// once per item. An item whose key fails cannot be ordered, so it is emitted as
+// soon as it arrives, after being passed to onError along with the error, if
+// onError is not nil. onError is called from the goroutine sending on the
+// returned channel. The returned channel must be drained, or that goroutine
+// leaks. WindowSort panics if window is less than 1.
+func WindowSort[Item any](in <-chan Item, window int, key func(Item) (interface{}, error), less Comparator, onError func(item Item, err error)) <-chan Item {
+	if window < 1 {
+		panic("keysort: WindowSort needs a window of at least 1")
+	}
+	out := make(chan Item)
+
+	go func() {
+		defer close(out)
+		// The buffer holds each item and its key in one of window slots, and
+		// the heap holds the slots that are in use.
+		items := make([]Item, window)
+		keys := make([]interface{}, window)
+		free := make([]int, window)
+		for i := range free {
+			free[i] = i
+		}
+		h := &minIndexHeap{indexSorter{nil, keys, less}}
+
+		emit := func() {
+			slot := heap.Pop(h).(int)
+			out <- items[slot]
+			var zero Item
+			items[slot], keys[slot] = zero, nil
+			free = append(free, slot)
+		}
+
+		for item := range in {
+			value, err := key(item)
+			if err != nil {
+				if onError != nil {
+					onError(item, err)
+				}
+				out <- item
+				continue
+			}
+			slot := free[len(free)-1]
+			free = free[:len(free)-1]
+			items[slot], keys[slot] = item, value
+			heap.Push(h, slot)
+			if h.Len() == window {
+				emit()
+			}
+		}
+		for h.Len() > 0 {
+			emit()
+		}
+	}()
+	return out
+}
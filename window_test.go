@@ -0,0 +1,94 @@
+package keysort
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func lessInts(a, b interface{}) bool {
+	return a.(int) < b.(int)
+}
+
+func intKey(i int) (interface{}, error) {
+	return i, nil
+}
+
+// feed sends items on a new channel, then closes it.
+func feed(items []int) <-chan int {
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for _, item := range items {
+			in <- item
+		}
+	}()
+	return in
+}
+
+func TestWindowSort(t *testing.T) {
+	const window = 5
+	// Shuffle within consecutive blocks of window items, so that no item is
+	// more than window-1 places from its sorted position.
+	items := make([]int, 10*SPECIMEN_SIZE)
+	for i := range items {
+		items[i] = i
+	}
+	for start := 0; start < len(items); start += window {
+		block := items[start:min(start+window, len(items))]
+		rand.Shuffle(len(block), func(i, j int) { block[i], block[j] = block[j], block[i] })
+	}
+
+	expected := 0
+	for item := range WindowSort(feed(items), window, intKey, lessInts, nil) {
+		if item != expected {
+			t.Fatalf("Expected %d, got %d", expected, item)
+		}
+		expected++
+	}
+	if expected != len(items) {
+		t.Errorf("Expected %d items, got %d", len(items), expected)
+	}
+}
+
+func TestWindowSortWholeStream(t *testing.T) {
+	// A window as large as the stream sorts any order.
+	got := []int{}
+	for item := range WindowSort(feed(rand.Perm(SPECIMEN_SIZE)), SPECIMEN_SIZE, intKey, lessInts, nil) {
+		got = append(got, item)
+	}
+
+	if len(got) != SPECIMEN_SIZE {
+		t.Fatalf("Expected %d items, got %d", SPECIMEN_SIZE, len(got))
+	}
+	for i := range got {
+		if got[i] != i {
+			t.Errorf("Expected %d at %d, got %d", i, i, got[i])
+		}
+	}
+}
+
+func TestWindowSortErrors(t *testing.T) {
+	items := []int{3, 2, 1, 0}
+	key := func(i int) (interface{}, error) {
+		if i == 1 {
+			return nil, fmt.Errorf("Blah")
+		}
+		return i, nil
+	}
+
+	got := []int{}
+	failed := map[int]error{}
+	onError := func(item int, err error) { failed[item] = err }
+	for item := range WindowSort(feed(items), len(items), key, lessInts, onError) {
+		got = append(got, item)
+	}
+
+	expected := []int{1, 0, 2, 3}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if len(failed) != 1 || failed[1] == nil {
+		t.Errorf("Expected the failed key of 1 to be reported, got %v", failed)
+	}
+}